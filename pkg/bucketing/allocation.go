@@ -2,28 +2,118 @@ package bucketing
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 
 	"github.com/twmb/murmur3"
 )
 
-var hash = murmur3.New32()
+// The different allocation algorithms
+const (
+	// AllocationV1 hashes the visitor ID only: a visitor gets the same bucket in every campaign
+	AllocationV1 = 1
+	// AllocationV2 hashes the visitor ID with the campaign salt, or the variation group ID if not set,
+	// so that the assignments of different campaigns are independent
+	AllocationV2 = 2
+)
+
+// defaultBucketSpace is the number of buckets visitors are hashed into when the campaign does not set it
+const defaultBucketSpace = 100
+
+// maxBucketSpace is the maximum number of buckets of a campaign, well below the 32 bits hash range
+const maxBucketSpace = 1000000
+
+// allocationEpsilon is the tolerance used when summing decimal allocations
+const allocationEpsilon = 1e-9
+
+// maxStackHashKeySize is the size of the hash key buffer kept on the stack when concatenating the allocation scope and the visitor ID
+const maxStackHashKeySize = 128
 
 // GetRandomAllocation returns a random allocation for a variationGroup
 func GetRandomAllocation(visitorID string, variationGroup *VariationGroup) (*Variation, error) {
-	hash.Reset()
-	_, err := hash.Write([]byte(visitorID))
+	return getAllocation(murmur3.StringSum32(visitorID), 0, variationGroup)
+}
 
+// GetCampaignAllocation returns a random allocation for a variationGroup of a campaign, using the campaign allocation algorithm.
+// It is safe for concurrent use and does not allocate memory when a variation is assigned
+func GetCampaignAllocation(visitorID string, campaign *Campaign, variationGroup *VariationGroup) (*Variation, error) {
+	hashed, err := allocationHash(visitorID, campaign, variationGroup)
 	if err != nil {
 		return nil, err
 	}
+	return getAllocation(hashed, campaign.BucketSpace, variationGroup)
+}
+
+// BucketingID returns the ID used to allocate the visitor in the campaign: the value of the campaign bucketing key
+// in the visitor context, or of the default bucketing key if the campaign does not set any. Visitors without
+// a string or number value for the key are bucketed by their visitor ID
+func BucketingID(visitorID string, campaign *Campaign, context map[string]interface{}, defaultBucketingKey string) string {
+	key := campaign.BucketingKey
+	if key == "" {
+		key = defaultBucketingKey
+	}
+	if key == "" {
+		return visitorID
+	}
+
+	switch v := context[key].(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	}
+	return visitorID
+}
+
+// allocationHash returns the hash of the visitor for the campaign allocation algorithm, an error if the algorithm is unknown
+func allocationHash(visitorID string, campaign *Campaign, variationGroup *VariationGroup) (uint32, error) {
+	switch campaign.AllocationVersion {
+	case 0, AllocationV1:
+		return murmur3.StringSum32(visitorID), nil
+	case AllocationV2:
+		scope := campaign.Salt
+		if scope == "" {
+			scope = variationGroup.ID
+		}
+		return scopedHash(scope, visitorID), nil
+	}
+	return 0, fmt.Errorf("Allocation version %d of campaign %s is unknown", campaign.AllocationVersion, campaign.ID)
+}
 
-	hashed := hash.Sum32()
-	z := hashed % 100
+// validAllocationVersion returns true if the allocation algorithm is known
+func validAllocationVersion(version int) bool {
+	return version == 0 || version == AllocationV1 || version == AllocationV2
+}
+
+// scopedHash returns the hash of the concatenation of a scope and an ID
+func scopedHash(scope string, id string) uint32 {
+	var buf [maxStackHashKeySize]byte
+	key := append(buf[:0], scope...)
+	key = append(key, id...)
+	return murmur3.Sum32(key)
+}
 
-	summedAlloc := 0
+// getAllocation returns the variation of the bucket the hash falls in. Variations allocations are percentages of the bucket space.
+// The default bucket space keeps the historical inclusive bucket bounds so that existing assignments do not change
+func getAllocation(hashed uint32, bucketSpace int, variationGroup *VariationGroup) (*Variation, error) {
+	legacy := bucketSpace <= 0
+	if legacy {
+		bucketSpace = defaultBucketSpace
+	}
+	if bucketSpace > maxBucketSpace {
+		return nil, fmt.Errorf("Bucket space %d is more than %d", bucketSpace, maxBucketSpace)
+	}
+	z := float64(hashed % uint32(bucketSpace))
+
+	summedAlloc := 0.0
 	for _, v := range variationGroup.Variations {
 		summedAlloc += v.Allocation
-		if int(z) <= summedAlloc {
+		bound := math.Round(summedAlloc * float64(bucketSpace) / 100)
+		if z < bound || (legacy && z == bound) {
 			return v, nil
 		}
 	}
@@ -31,3 +121,29 @@ func GetRandomAllocation(visitorID string, variationGroup *VariationGroup) (*Var
 	// If no variation alloc, returns empty
 	return nil, fmt.Errorf("Visitor untracked for vg ID : %s", variationGroup.ID)
 }
+
+// ValidateAllocation checks that the variation allocations are positive, do not exceed 100% in total
+// and can be represented in the bucket space
+func ValidateAllocation(variationGroup *VariationGroup, bucketSpace int) error {
+	if bucketSpace <= 0 {
+		bucketSpace = defaultBucketSpace
+	}
+
+	summedAlloc := 0.0
+	for _, v := range variationGroup.Variations {
+		if v.Allocation < 0 {
+			return fmt.Errorf("Variation %s allocation %v is negative", v.ID, v.Allocation)
+		}
+
+		buckets := v.Allocation * float64(bucketSpace) / 100
+		if math.Abs(buckets-math.Round(buckets)) > allocationEpsilon {
+			return fmt.Errorf("Variation %s allocation %v cannot be represented with %d buckets", v.ID, v.Allocation, bucketSpace)
+		}
+		summedAlloc += v.Allocation
+	}
+
+	if summedAlloc > 100+allocationEpsilon {
+		return fmt.Errorf("Variation group %s allocations sum to %v, more than 100", variationGroup.ID, summedAlloc)
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package bucketing
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"math/rand"
 	"strconv"
+	"sync"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/murmur3"
 )
 
 func testVariationGroupAlloc(vg VariationGroup, t *testing.T) {
@@ -42,7 +48,7 @@ func testVariationGroupAlloc(vg VariationGroup, t *testing.T) {
 		}
 	}
 
-	sumAllocVars := 0
+	sumAllocVars := 0.0
 	for _, v := range vg.Variations {
 		sumAllocVars += v.Allocation
 	}
@@ -93,3 +99,248 @@ func TestVariationAllocation(t *testing.T) {
 	}
 	testVariationGroupAlloc(variationsGroupInfo, t)
 }
+
+func TestCampaignAllocation(t *testing.T) {
+	newVg := func(id string) *VariationGroup {
+		return &VariationGroup{
+			ID: id,
+			Variations: []*Variation{
+				{ID: "1", Allocation: 50},
+				{ID: "2", Allocation: 50},
+			},
+		}
+	}
+	vg1, vg2 := newVg("vg1"), newVg("vg2")
+
+	countSame := func(c1 *Campaign, c2 *Campaign) int {
+		same := 0
+		for i := 0; i < 10000; i++ {
+			vID := strconv.Itoa(i)
+			v1, _ := GetCampaignAllocation(vID, c1, vg1)
+			v2, _ := GetCampaignAllocation(vID, c2, vg2)
+			if v1 != nil && v2 != nil && v1.ID == v2.ID {
+				same++
+			}
+		}
+		return same
+	}
+
+	// Legacy allocation keeps the visitor in the same variation in every campaign
+	v1, _ := GetCampaignAllocation("visitor", &Campaign{}, vg1)
+	v2, _ := GetRandomAllocation("visitor", vg1)
+	if v1 != v2 {
+		t.Error("Legacy campaign allocation should be the same as the random allocation")
+	}
+
+	if same := countSame(&Campaign{}, &Campaign{AllocationVersion: AllocationV1}); same < 9900 {
+		t.Errorf("Legacy allocation should be correlated. Got %d same variations out of 10000", same)
+	}
+
+	if same := countSame(&Campaign{AllocationVersion: AllocationV2}, &Campaign{AllocationVersion: AllocationV2}); math.Abs(float64(same)-5000) > 500 {
+		t.Errorf("Scoped allocation should be independent. Got %d same variations out of 10000", same)
+	}
+
+	if same := countSame(&Campaign{AllocationVersion: AllocationV2, Salt: "s"}, &Campaign{AllocationVersion: AllocationV2, Salt: "s"}); same < 9900 {
+		t.Errorf("Scoped allocation with the same salt should be correlated. Got %d same variations out of 10000", same)
+	}
+}
+
+func createBenchmarkVariationGroup() *VariationGroup {
+	return &VariationGroup{
+		ID: "benchmark_vgid",
+		Variations: []*Variation{
+			{ID: "1", Allocation: 33},
+			{ID: "2", Allocation: 33},
+			{ID: "3", Allocation: 34},
+		},
+	}
+}
+
+func TestConcurrentAllocation(t *testing.T) {
+	vg := createBenchmarkVariationGroup()
+	campaigns := []*Campaign{{}, {AllocationVersion: AllocationV2}}
+
+	expected := map[string]string{}
+	for _, c := range campaigns {
+		for i := 0; i < 1000; i++ {
+			v, _ := GetCampaignAllocation(strconv.Itoa(i), c, vg)
+			expected[fmt.Sprintf("%d_%d", c.AllocationVersion, i)] = v.ID
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, c := range campaigns {
+				for i := 0; i < 1000; i++ {
+					v, _ := GetCampaignAllocation(strconv.Itoa(i), c, vg)
+					if v.ID != expected[fmt.Sprintf("%d_%d", c.AllocationVersion, i)] {
+						t.Errorf("Concurrent allocation is not deterministic for visitor %d", i)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAllocationNoAlloc(t *testing.T) {
+	vg := createBenchmarkVariationGroup()
+	campaign := &Campaign{AllocationVersion: AllocationV2}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		GetCampaignAllocation("test_visitor_id", campaign, vg)
+	})
+	if allocs != 0 {
+		t.Errorf("Allocation should not allocate memory. Got %v allocs per run", allocs)
+	}
+}
+
+func BenchmarkGetCampaignAllocationV1(b *testing.B) {
+	vg := createBenchmarkVariationGroup()
+	campaign := &Campaign{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetCampaignAllocation("test_visitor_id", campaign, vg)
+	}
+}
+
+func BenchmarkGetCampaignAllocationV2(b *testing.B) {
+	vg := createBenchmarkVariationGroup()
+	campaign := &Campaign{AllocationVersion: AllocationV2}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetCampaignAllocation("test_visitor_id", campaign, vg)
+	}
+}
+
+func BenchmarkGetCampaignAllocationParallel(b *testing.B) {
+	vg := createBenchmarkVariationGroup()
+	campaign := &Campaign{AllocationVersion: AllocationV2}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			GetCampaignAllocation("test_visitor_id", campaign, vg)
+		}
+	})
+}
+
+func TestDecimalAllocation(t *testing.T) {
+	vg := &VariationGroup{
+		ID: "vgid",
+		Variations: []*Variation{
+			{ID: "1", Allocation: 0.5},
+			{ID: "2", Allocation: 99.5},
+		},
+	}
+	campaign := &Campaign{BucketSpace: 10000}
+
+	counts := map[string]int{}
+	countTotal := 200000
+	for i := 0; i < countTotal; i++ {
+		v, err := GetCampaignAllocation(strconv.Itoa(i), campaign, vg)
+		if err != nil {
+			t.Errorf("Unexpected untracked visitor : %v", err)
+			continue
+		}
+		counts[v.ID]++
+	}
+
+	ratio := float64(counts["1"]) / float64(countTotal)
+	if math.Abs(ratio-0.005) > 0.001 {
+		t.Errorf("Problem with stats: ratio %f, correctRatio : %f", ratio, 0.005)
+	}
+}
+
+func TestBucketSpaceOutOfRange(t *testing.T) {
+	config := &Configuration{}
+	err := json.Unmarshal([]byte(`{"campaigns":[{"id":"cid","bucketSpace":4294967296,"variationGroups":[{"id":"vgid","variations":[{"id":"1","allocation":100}]}]}]}`), config)
+	assert.Nil(t, err)
+
+	errs := ValidateConfiguration(config).(ValidationErrors)
+	assert.NotNil(t, errs)
+
+	_, err = GetCampaignAllocation("vid", config.Campaigns[0], config.Campaigns[0].VariationGroups[0])
+	assert.NotNil(t, err)
+}
+
+func TestUnknownAllocationVersion(t *testing.T) {
+	campaign := &Campaign{ID: "cid", AllocationVersion: 3}
+	vg := &VariationGroup{ID: "vgid", Variations: []*Variation{{ID: "1", Allocation: 100}}}
+
+	_, err := GetCampaignAllocation("vid", campaign, vg)
+	assert.NotNil(t, err)
+
+	errs := ValidateConfiguration(&Configuration{Campaigns: []*Campaign{{ID: "cid", AllocationVersion: -1}}}).(ValidationErrors)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "campaigns[0]", errs[0].Path)
+}
+
+func TestLegacyAllocationUnchanged(t *testing.T) {
+	vg := &VariationGroup{
+		Variations: []*Variation{
+			{ID: "1", Allocation: 10},
+			{ID: "2", Allocation: 25},
+			{ID: "3", Allocation: 35},
+		},
+	}
+
+	for i := 0; i < 10000; i++ {
+		vID := strconv.Itoa(i)
+		v, _ := GetRandomAllocation(vID, vg)
+
+		// Historical algorithm
+		z := int(murmur3.StringSum32(vID) % 100)
+		var expected *Variation
+		summedAlloc := 0
+		for _, variation := range vg.Variations {
+			summedAlloc += int(variation.Allocation)
+			if z <= summedAlloc {
+				expected = variation
+				break
+			}
+		}
+
+		if v != expected {
+			t.Errorf("Legacy allocation changed for visitor %s", vID)
+		}
+	}
+}
+
+func TestValidateAllocation(t *testing.T) {
+	vg := &VariationGroup{
+		ID: "vgid",
+		Variations: []*Variation{
+			{ID: "1", Allocation: 33.3},
+			{ID: "2", Allocation: 33.3},
+			{ID: "3", Allocation: 33.4},
+		},
+	}
+	assert.Nil(t, ValidateAllocation(vg, 1000))
+	assert.NotNil(t, ValidateAllocation(vg, 0))
+
+	vg.Variations[2].Allocation = 34
+	assert.NotNil(t, ValidateAllocation(vg, 1000))
+
+	vg.Variations[2].Allocation = -1
+	assert.NotNil(t, ValidateAllocation(vg, 1000))
+}
+
+func TestBucketingID(t *testing.T) {
+	campaign := &Campaign{BucketingKey: "company_id"}
+
+	assert.Equal(t, "acme", BucketingID("vid", campaign, map[string]interface{}{"company_id": "acme"}, ""))
+	assert.Equal(t, "42", BucketingID("vid", campaign, map[string]interface{}{"company_id": 42.0}, ""))
+	assert.Equal(t, "vid", BucketingID("vid", campaign, map[string]interface{}{}, ""))
+	assert.Equal(t, "vid", BucketingID("vid", campaign, map[string]interface{}{"company_id": ""}, ""))
+	assert.Equal(t, "vid", BucketingID("vid", campaign, map[string]interface{}{"company_id": true}, ""))
+
+	assert.Equal(t, "vid", BucketingID("vid", &Campaign{}, map[string]interface{}{"company_id": "acme"}, ""))
+	assert.Equal(t, "acme", BucketingID("vid", &Campaign{}, map[string]interface{}{"company_id": "acme"}, "company_id"))
+	assert.Equal(t, "eu", BucketingID("vid", &Campaign{BucketingKey: "region"}, map[string]interface{}{"company_id": "acme", "region": "eu"}, "company_id"))
+}
@@ -3,6 +3,7 @@ package bucketing
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/abtasty/flagship-go-sdk/pkg/logging"
@@ -89,18 +90,12 @@ func NewAPIClient(envID string, params ...func(*APIClient)) *APIClient {
 
 // GetConfiguration gets an environment configuration from API
 func (r APIClient) GetConfiguration() (*Configuration, error) {
-	path := fmt.Sprintf("/%s/bucketing.json", r.envID)
-
-	response, _, code, err := r.httpRequest.Do(path, "GET", nil)
+	response, _, err := r.getConfigurationPayload()
 
 	if err != nil {
 		return nil, err
 	}
 
-	if code != 200 && code != 304 {
-		return nil, fmt.Errorf("Error when calling Bucketing API : %v", err)
-	}
-
 	resp := &Configuration{}
 	err = json.Unmarshal(response, &resp)
 
@@ -110,3 +105,36 @@ func (r APIClient) GetConfiguration() (*Configuration, error) {
 
 	return resp, nil
 }
+
+// GetSignedConfiguration gets an environment configuration payload and its signature from API
+func (r APIClient) GetSignedConfiguration() ([]byte, []byte, error) {
+	response, headers, err := r.getConfigurationPayload()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := decodeSignature(headers.Get(SignatureHeader))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, signature, nil
+}
+
+// getConfigurationPayload gets the raw environment configuration and the response headers from API
+func (r APIClient) getConfigurationPayload() ([]byte, http.Header, error) {
+	path := fmt.Sprintf("/%s/bucketing.json", r.envID)
+
+	response, headers, code, err := r.httpRequest.Do(path, "GET", nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if code != 200 && code != 304 {
+		return nil, nil, fmt.Errorf("Error when calling Bucketing API : %v", err)
+	}
+
+	return response, headers, nil
+}
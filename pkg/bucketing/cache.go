@@ -0,0 +1,157 @@
+package bucketing
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// cachedConfiguration represents a bucketing configuration persisted on disk
+type cachedConfiguration struct {
+	Checksum      string          `json:"checksum"`
+	Configuration json.RawMessage `json:"configuration"`
+	Signature     string          `json:"signature,omitempty"`
+}
+
+// CacheDir sets the directory where the last loaded configuration is persisted and loaded from at startup
+func CacheDir(dir string) func(r *Engine) {
+	return func(r *Engine) {
+		r.cacheDir = dir
+	}
+}
+
+// cacheFile returns the path of the configuration cache file for the engine env ID
+func (b *Engine) cacheFile() string {
+	return filepath.Join(b.cacheDir, fmt.Sprintf("bucketing_%s.json", b.envID))
+}
+
+// loadCache loads the configuration persisted on disk if any
+func (b *Engine) loadCache() error {
+	if b.cacheDir == "" {
+		return nil
+	}
+
+	config, err := readConfigurationCache(b.cacheFile(), b.signatureKey)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateConfiguration(config); err != nil {
+		return err
+	}
+
+	logger.Info("Loaded bucketing configuration from cache")
+	b.setConfiguration(config)
+	return nil
+}
+
+// writeCache persists the configuration on disk
+func (b *Engine) writeCache(config *Configuration) {
+	if b.cacheDir == "" {
+		return
+	}
+
+	data, err := json.Marshal(config)
+	if err == nil {
+		err = writeConfigurationCache(b.cacheFile(), data, nil)
+	}
+	if err != nil {
+		logger.Error("Error when writing configuration cache", err)
+	}
+}
+
+// writeSignedCache persists the signed configuration payload and its signature on disk
+func (b *Engine) writeSignedCache(payload []byte, signature []byte) {
+	if b.cacheDir == "" {
+		return
+	}
+
+	err := writeConfigurationCache(b.cacheFile(), payload, signature)
+	if err != nil {
+		logger.Error("Error when writing configuration cache", err)
+	}
+}
+
+// writeConfigurationCache writes the configuration payload, its checksum and signature in a temporary file and renames it to the cache path
+func writeConfigurationCache(path string, payload []byte, signature []byte) error {
+	checksum := sha256.Sum256(payload)
+	content, err := json.Marshal(cachedConfiguration{
+		Checksum:      hex.EncodeToString(checksum[:]),
+		Configuration: payload,
+		Signature:     base64.StdEncoding.EncodeToString(signature),
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// readConfigurationCache reads a cached configuration and verifies its checksum, and its signature if a public key is given
+func readConfigurationCache(path string, publicKey ed25519.PublicKey) (*Configuration, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := cachedConfiguration{}
+	err = json.Unmarshal(content, &cached)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(cached.Configuration)
+	if hex.EncodeToString(checksum[:]) != cached.Checksum {
+		return nil, errors.New("Configuration cache checksum mismatch")
+	}
+
+	if publicKey != nil {
+		signature, err := decodeSignature(cached.Signature)
+		if err != nil {
+			return nil, err
+		}
+		err = verifySignature(publicKey, cached.Configuration, signature)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config := &Configuration{}
+	err = json.Unmarshal(cached.Configuration, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,73 @@
+package bucketing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sub", "bucketing.json")
+	config := &Configuration{
+		Panic: true,
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+		}},
+	}
+
+	data, err := json.Marshal(config)
+	assert.Nil(t, err)
+
+	err = writeConfigurationCache(path, data, nil)
+	assert.Nil(t, err)
+
+	cached, err := readConfigurationCache(path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, true, cached.Panic)
+	assert.Equal(t, "test_cid", cached.Campaigns[0].ID)
+
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	assert.Equal(t, 1, len(files))
+
+	err = ioutil.WriteFile(path, []byte(`{"checksum":"abc","configuration":{"panic":false}}`), 0644)
+	assert.Nil(t, err)
+
+	_, err = readConfigurationCache(path, nil)
+	assert.NotNil(t, err)
+
+	_, err = readConfigurationCache(filepath.Join(dir, "missing.json"), nil)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEngineCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), CacheDir(dir))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+		}},
+	}, 200)
+	err = engine.Load()
+	assert.Nil(t, err)
+
+	// The new engine cannot reach the API but starts with the cached configuration
+	engine, err = NewEngine(testEnvID, eg, PollingInterval(-1), CacheDir(dir), APIOptions(APIUrl("http://127.0.0.1:1")))
+	assert.NotNil(t, err)
+	assert.NotNil(t, engine.getConfig())
+	assert.Equal(t, "test_cid", engine.getConfig().Campaigns[0].ID)
+}
@@ -0,0 +1,129 @@
+package bucketing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CurrentTimeContextKey is the context key matched against the current time of the engine if not set by the visitor
+const CurrentTimeContextKey = "fs_current_time"
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// loadTimeZone returns the location of the targeting time zone, UTC if not set
+func loadTimeZone(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time zone %q : %v", timeZone, err)
+	}
+	return loc, nil
+}
+
+// parseTime parses a date time value. Strings can be RFC3339 date times or dates, interpreted in the given location
+// if they do not hold an offset. Numbers are Unix timestamps in seconds
+func parseTime(value interface{}, loc *time.Location) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t.In(loc), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("Invalid date time %q", v)
+	case float64:
+		return time.Unix(int64(v), 0).In(loc), nil
+	case int:
+		return time.Unix(int64(v), 0).In(loc), nil
+	case time.Time:
+		return v.In(loc), nil
+	}
+	return time.Time{}, fmt.Errorf("Date time value %v must be a string or a number", value)
+}
+
+// parseWeekday parses a day of week from its name ("saturday", "sat") or its number (0 for sunday)
+func parseWeekday(value interface{}) (time.Weekday, error) {
+	switch v := value.(type) {
+	case string:
+		name := strings.ToLower(strings.TrimSpace(v))
+		for dayName, day := range weekdays {
+			if name == dayName || (len(name) == 3 && strings.HasPrefix(dayName, name)) {
+				return day, nil
+			}
+		}
+	case float64:
+		if v >= 0 && v <= 6 && v == float64(int(v)) {
+			return time.Weekday(int(v)), nil
+		}
+	case int:
+		if v >= 0 && v <= 6 {
+			return time.Weekday(v), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid day of week %v", value)
+}
+
+// parseHourRange parses an hour of day range, either as a "9-17" string or a list of two numbers.
+// The start hour is included and the end hour excluded
+func parseHourRange(value interface{}) (int, int, error) {
+	bounds := []interface{}{}
+	switch v := value.(type) {
+	case string:
+		for _, b := range strings.Split(v, "-") {
+			bounds = append(bounds, strings.TrimSpace(b))
+		}
+	default:
+		list, ok := takeSliceArg(value)
+		if !ok {
+			return 0, 0, fmt.Errorf("Invalid hour range %v", value)
+		}
+		bounds = list
+	}
+
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("Invalid hour range %v : expected a start and an end", value)
+	}
+
+	hours := []int{}
+	for _, b := range bounds {
+		hour := -1
+		switch h := b.(type) {
+		case string:
+			if n, err := strconv.Atoi(h); err == nil {
+				hour = n
+			}
+		case float64:
+			hour = int(h)
+		case int:
+			hour = h
+		}
+		if hour < 0 || hour > 24 {
+			return 0, 0, fmt.Errorf("Invalid hour %v in range %v", b, value)
+		}
+		hours = append(hours, hour)
+	}
+	return hours[0], hours[1], nil
+}
+
+// currentTimeValue returns the current time matched by a targeting on CurrentTimeContextKey when the visitor did not set it.
+// Date operators use the time as is, other operators compare it formatted as RFC3339
+func currentTimeValue(operator TargetingOperator, now time.Time) interface{} {
+	if isDateOperator(operator) {
+		return now
+	}
+	return now.Format(time.RFC3339)
+}
@@ -0,0 +1,104 @@
+package bucketing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTargetingDate(operator TargetingOperator, targetingValue interface{}, value interface{}, timeZone string, t *testing.T, shouldMatch bool, shouldRaiseError bool) {
+	match, err := targetingMatchOperatorDate(operator, targetingValue, value, timeZone)
+
+	if ((err != nil && !shouldRaiseError) || (shouldRaiseError && err == nil)) || (match != shouldMatch) {
+		t.Errorf("Targeting date %v not working - tv : %v, v: %v, match : %v, err: %v", operator, targetingValue, value, match, err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tm, err := parseTime("2020-05-01T10:00:00+02:00", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, tm.UTC().Hour())
+
+	tm, err = parseTime("2020-05-01", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), tm)
+
+	tm, err = parseTime(1588327200.0, time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC), tm)
+
+	_, err = parseTime("tomorrow", time.UTC)
+	assert.NotNil(t, err)
+	_, err = parseTime(true, time.UTC)
+	assert.NotNil(t, err)
+}
+
+func TestDateTargeting(t *testing.T) {
+	testTargetingDate(DATE_BEFORE, "2020-05-02", "2020-05-01T23:00:00Z", "", t, true, false)
+	testTargetingDate(DATE_BEFORE, "2020-05-02", "2020-05-02T01:00:00Z", "", t, false, false)
+	testTargetingDate(DATE_BEFORE, "2020-05-02", "2020-05-01T23:00:00Z", "Europe/Paris", t, false, false)
+	testTargetingDate(DATE_AFTER, "2020-05-01T10:00:00Z", "2020-05-01T10:00:01Z", "", t, true, false)
+	testTargetingDate(DATE_AFTER, "2020-05-01T10:00:00Z", 1588327200.0, "", t, false, false)
+	testTargetingDate(DATE_AFTER, "2020-05-01", "abc", "", t, false, true)
+	testTargetingDate(DATE_AFTER, "2020-05-01", "2020-05-01", "Mars/Olympus", t, false, true)
+
+	testTargetingDate(DATE_BETWEEN, []interface{}{"2020-05-01", "2020-05-02"}, "2020-05-01T12:00:00Z", "", t, true, false)
+	testTargetingDate(DATE_BETWEEN, []interface{}{"2020-05-01", "2020-05-02"}, "2020-05-02T00:00:00Z", "", t, false, false)
+	testTargetingDate(DATE_BETWEEN, "2020-05-01", "2020-05-01T12:00:00Z", "", t, false, true)
+
+	// 2020-05-02 is a saturday
+	testTargetingDate(DAY_OF_WEEK, []interface{}{"saturday", "sun"}, "2020-05-02T12:00:00Z", "", t, true, false)
+	testTargetingDate(DAY_OF_WEEK, []interface{}{"saturday", "sun"}, "2020-05-01T12:00:00Z", "", t, false, false)
+	testTargetingDate(DAY_OF_WEEK, "saturday", "2020-05-01T23:00:00Z", "Europe/Paris", t, true, false)
+	testTargetingDate(DAY_OF_WEEK, 6.0, "2020-05-02T12:00:00Z", "", t, true, false)
+	testTargetingDate(DAY_OF_WEEK, "someday", "2020-05-02T12:00:00Z", "", t, false, true)
+
+	testTargetingDate(HOUR_OF_DAY, "9-17", "2020-05-01T09:00:00Z", "", t, true, false)
+	testTargetingDate(HOUR_OF_DAY, "9-17", "2020-05-01T17:00:00Z", "", t, false, false)
+	testTargetingDate(HOUR_OF_DAY, []interface{}{9.0, 17.0}, "2020-05-01T16:59:00Z", "", t, true, false)
+	testTargetingDate(HOUR_OF_DAY, "9-17", "2020-05-01T08:00:00Z", "Europe/Paris", t, true, false)
+	testTargetingDate(HOUR_OF_DAY, "22-6", "2020-05-01T23:00:00Z", "", t, true, false)
+	testTargetingDate(HOUR_OF_DAY, "22-6", "2020-05-01T12:00:00Z", "", t, false, false)
+	testTargetingDate(HOUR_OF_DAY, "9", "2020-05-01T12:00:00Z", "", t, false, true)
+}
+
+func TestCurrentTimeTargeting(t *testing.T) {
+	now := time.Date(2020, 5, 2, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, currentTimeValue(DAY_OF_WEEK, now))
+	assert.Equal(t, "2020-05-02T12:00:00Z", currentTimeValue(EQUALS, now))
+
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{
+				Targetings: []*Targeting{{
+					Operator: DAY_OF_WEEK,
+					Key:      CurrentTimeContextKey,
+					Value:    []interface{}{"saturday", "sunday"},
+				}},
+			}},
+		},
+	}
+
+	match, err := targetingMatchAt(vg, testVID, map[string]interface{}{}, now)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	match, err = targetingMatchAt(vg, testVID, map[string]interface{}{}, now.Add(-24*time.Hour))
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	// The visitor current time takes precedence over the engine one
+	match, err = targetingMatchAt(vg, testVID, map[string]interface{}{CurrentTimeContextKey: "2020-05-01"}, now)
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	match, err = compileVariationGroup(vg)(testVID, map[string]interface{}{}, now)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	// Without a current time, the targeting only matches the visitor one
+	match, err = TargetingMatch(vg, testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.False(t, match)
+}
@@ -0,0 +1,187 @@
+package bucketing
+
+import (
+	"reflect"
+)
+
+// ConfigurationDiff represents the changes between two bucketing configurations
+type ConfigurationDiff struct {
+	PanicChanged     bool
+	Panic            bool
+	LayersChanged    bool
+	HoldoutChanged   bool
+	AddedCampaigns   []string
+	RemovedCampaigns []string
+	ChangedCampaigns []*CampaignDiff
+}
+
+// CampaignDiff represents the changes of a campaign between two bucketing configurations
+type CampaignDiff struct {
+	ID                        string
+	AddedVariationGroups      []string
+	RemovedVariationGroups    []string
+	TargetingChangedGroups    []string
+	AllocationChangedGroups   []string
+	ModificationChangedGroups []string
+	ScheduleChangedGroups     []string
+	TrafficAllocationChanged  bool
+	AllocationChanged         bool
+	PriorityChanged           bool
+	ScheduleChanged           bool
+	RolloutChanged            bool
+}
+
+// IsEmpty returns true if the diff does not contain any change
+func (d *ConfigurationDiff) IsEmpty() bool {
+	return !d.PanicChanged &&
+		!d.LayersChanged &&
+		!d.HoldoutChanged &&
+		len(d.AddedCampaigns) == 0 &&
+		len(d.RemovedCampaigns) == 0 &&
+		len(d.ChangedCampaigns) == 0
+}
+
+func (d *CampaignDiff) isEmpty() bool {
+	return !d.TrafficAllocationChanged &&
+		!d.AllocationChanged &&
+		!d.PriorityChanged &&
+		!d.ScheduleChanged &&
+		!d.RolloutChanged &&
+		len(d.ScheduleChangedGroups) == 0 &&
+		len(d.AddedVariationGroups) == 0 &&
+		len(d.RemovedVariationGroups) == 0 &&
+		len(d.TargetingChangedGroups) == 0 &&
+		len(d.AllocationChangedGroups) == 0 &&
+		len(d.ModificationChangedGroups) == 0
+}
+
+// DiffConfigurations computes the campaign level changes between an old and a new configuration.
+// A nil configuration is considered as an empty one
+func DiffConfigurations(oldConfig *Configuration, newConfig *Configuration) *ConfigurationDiff {
+	if oldConfig == nil {
+		oldConfig = &Configuration{}
+	}
+	if newConfig == nil {
+		newConfig = &Configuration{}
+	}
+
+	diff := &ConfigurationDiff{
+		PanicChanged:     oldConfig.Panic != newConfig.Panic,
+		Panic:            newConfig.Panic,
+		LayersChanged:    !reflect.DeepEqual(oldConfig.Layers, newConfig.Layers),
+		HoldoutChanged:   !reflect.DeepEqual(oldConfig.Holdout, newConfig.Holdout),
+		AddedCampaigns:   []string{},
+		RemovedCampaigns: []string{},
+		ChangedCampaigns: []*CampaignDiff{},
+	}
+
+	oldCampaigns := map[string]*Campaign{}
+	for _, c := range oldConfig.Campaigns {
+		oldCampaigns[c.ID] = c
+	}
+
+	newCampaigns := map[string]*Campaign{}
+	for _, c := range newConfig.Campaigns {
+		newCampaigns[c.ID] = c
+		oldCampaign, ok := oldCampaigns[c.ID]
+		if !ok {
+			diff.AddedCampaigns = append(diff.AddedCampaigns, c.ID)
+			continue
+		}
+
+		campaignDiff := diffCampaigns(oldCampaign, c)
+		if !campaignDiff.isEmpty() {
+			diff.ChangedCampaigns = append(diff.ChangedCampaigns, campaignDiff)
+		}
+	}
+
+	for _, c := range oldConfig.Campaigns {
+		if _, ok := newCampaigns[c.ID]; !ok {
+			diff.RemovedCampaigns = append(diff.RemovedCampaigns, c.ID)
+		}
+	}
+
+	return diff
+}
+
+func diffCampaigns(oldCampaign *Campaign, newCampaign *Campaign) *CampaignDiff {
+	diff := &CampaignDiff{
+		ID:                        newCampaign.ID,
+		AddedVariationGroups:      []string{},
+		RemovedVariationGroups:    []string{},
+		TargetingChangedGroups:    []string{},
+		AllocationChangedGroups:   []string{},
+		ModificationChangedGroups: []string{},
+		ScheduleChangedGroups:     []string{},
+		TrafficAllocationChanged:  !reflect.DeepEqual(oldCampaign.TrafficAllocation, newCampaign.TrafficAllocation),
+		AllocationChanged:         campaignAllocationChanged(oldCampaign, newCampaign),
+		PriorityChanged:           oldCampaign.Priority != newCampaign.Priority,
+		ScheduleChanged:           scheduleChanged(oldCampaign.StartDate, oldCampaign.EndDate, newCampaign.StartDate, newCampaign.EndDate),
+		RolloutChanged:            rolloutChanged(oldCampaign.RolloutSteps, newCampaign.RolloutSteps),
+	}
+
+	oldGroups := map[string]*VariationGroup{}
+	for _, vg := range oldCampaign.VariationGroups {
+		oldGroups[vg.ID] = vg
+	}
+
+	newGroups := map[string]*VariationGroup{}
+	for _, vg := range newCampaign.VariationGroups {
+		newGroups[vg.ID] = vg
+		oldVg, ok := oldGroups[vg.ID]
+		if !ok {
+			diff.AddedVariationGroups = append(diff.AddedVariationGroups, vg.ID)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldVg.Targeting, vg.Targeting) {
+			diff.TargetingChangedGroups = append(diff.TargetingChangedGroups, vg.ID)
+		}
+
+		if scheduleChanged(oldVg.StartDate, oldVg.EndDate, vg.StartDate, vg.EndDate) {
+			diff.ScheduleChangedGroups = append(diff.ScheduleChangedGroups, vg.ID)
+		}
+
+		allocationChanged, modificationChanged := diffVariations(oldVg.Variations, vg.Variations)
+		if allocationChanged {
+			diff.AllocationChangedGroups = append(diff.AllocationChangedGroups, vg.ID)
+		}
+		if modificationChanged {
+			diff.ModificationChangedGroups = append(diff.ModificationChangedGroups, vg.ID)
+		}
+	}
+
+	for _, vg := range oldCampaign.VariationGroups {
+		if _, ok := newGroups[vg.ID]; !ok {
+			diff.RemovedVariationGroups = append(diff.RemovedVariationGroups, vg.ID)
+		}
+	}
+
+	return diff
+}
+
+// campaignAllocationChanged returns true if the campaign fields used to hash visitors changed, reassigning its visitors
+func campaignAllocationChanged(oldCampaign *Campaign, newCampaign *Campaign) bool {
+	return oldCampaign.AllocationVersion != newCampaign.AllocationVersion ||
+		oldCampaign.Salt != newCampaign.Salt ||
+		oldCampaign.BucketSpace != newCampaign.BucketSpace ||
+		oldCampaign.BucketingKey != newCampaign.BucketingKey
+}
+
+// diffVariations returns whether the allocation or the modifications of the variations changed
+func diffVariations(oldVariations []*Variation, newVariations []*Variation) (allocationChanged bool, modificationChanged bool) {
+	if len(oldVariations) != len(newVariations) {
+		return true, true
+	}
+
+	for i, v := range newVariations {
+		oldV := oldVariations[i]
+		if oldV.ID != v.ID || oldV.Allocation != v.Allocation || oldV.Reference != v.Reference {
+			allocationChanged = true
+		}
+		if !reflect.DeepEqual(oldV.Modifications, v.Modifications) {
+			modificationChanged = true
+		}
+	}
+	return allocationChanged, modificationChanged
+}
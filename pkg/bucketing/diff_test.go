@@ -0,0 +1,137 @@
+package bucketing
+
+import (
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/decision"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDiffTestConfig() *Configuration {
+	return &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "c1",
+			VariationGroups: []*VariationGroup{{
+				ID: "vg1",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: []*Targeting{{
+							Operator: EQUALS,
+							Key:      "test",
+							Value:    true,
+						}},
+					}},
+				},
+				Variations: []*Variation{{
+					ID:         "v1",
+					Allocation: 50,
+					Modifications: decision.APIClientModification{
+						Type:  "FLAG",
+						Value: map[string]interface{}{"test": true},
+					},
+				}, {
+					ID:         "v2",
+					Allocation: 50,
+					Reference:  true,
+				}},
+			}},
+		}, {
+			ID: "c2",
+		}},
+	}
+}
+
+func TestDiffConfigurationsEmpty(t *testing.T) {
+	diff := DiffConfigurations(createDiffTestConfig(), createDiffTestConfig())
+	assert.True(t, diff.IsEmpty())
+
+	diff = DiffConfigurations(nil, nil)
+	assert.True(t, diff.IsEmpty())
+}
+
+func TestDiffConfigurationsCampaigns(t *testing.T) {
+	diff := DiffConfigurations(nil, createDiffTestConfig())
+	assert.Equal(t, []string{"c1", "c2"}, diff.AddedCampaigns)
+	assert.Equal(t, 0, len(diff.RemovedCampaigns))
+
+	newConfig := createDiffTestConfig()
+	newConfig.Campaigns[1].ID = "c3"
+	newConfig.Panic = true
+
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.True(t, diff.PanicChanged)
+	assert.True(t, diff.Panic)
+	assert.Equal(t, []string{"c3"}, diff.AddedCampaigns)
+	assert.Equal(t, []string{"c2"}, diff.RemovedCampaigns)
+	assert.Equal(t, 0, len(diff.ChangedCampaigns))
+}
+
+func TestDiffConfigurationsVariationGroups(t *testing.T) {
+	newConfig := createDiffTestConfig()
+	vg := newConfig.Campaigns[0].VariationGroups[0]
+	vg.Targeting.TargetingGroups[0].Targetings[0].Value = false
+	vg.Variations[0].Allocation = 20
+	vg.Variations[1].Allocation = 80
+
+	diff := DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.False(t, diff.PanicChanged)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.Equal(t, "c1", diff.ChangedCampaigns[0].ID)
+	assert.Equal(t, []string{"vg1"}, diff.ChangedCampaigns[0].TargetingChangedGroups)
+	assert.Equal(t, []string{"vg1"}, diff.ChangedCampaigns[0].AllocationChangedGroups)
+	assert.Equal(t, 0, len(diff.ChangedCampaigns[0].ModificationChangedGroups))
+
+	newConfig = createDiffTestConfig()
+	newConfig.Campaigns[0].VariationGroups[0].Variations[0].Modifications.Value["test"] = false
+	newConfig.Campaigns[0].VariationGroups = append(newConfig.Campaigns[0].VariationGroups, &VariationGroup{ID: "vg2"})
+
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.Equal(t, []string{"vg2"}, diff.ChangedCampaigns[0].AddedVariationGroups)
+	assert.Equal(t, []string{"vg1"}, diff.ChangedCampaigns[0].ModificationChangedGroups)
+	assert.Equal(t, 0, len(diff.ChangedCampaigns[0].AllocationChangedGroups))
+
+	diff = DiffConfigurations(newConfig, createDiffTestConfig())
+	assert.Equal(t, []string{"vg2"}, diff.ChangedCampaigns[0].RemovedVariationGroups)
+}
+
+func TestDiffConfigurationsCampaignAllocation(t *testing.T) {
+	diff := DiffConfigurations(createDiffTestConfig(), createDiffTestConfig())
+	assert.True(t, diff.IsEmpty())
+
+	newConfig := createDiffTestConfig()
+	newConfig.Campaigns[0].AllocationVersion = 2
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.True(t, diff.ChangedCampaigns[0].AllocationChanged)
+
+	newConfig = createDiffTestConfig()
+	newConfig.Campaigns[0].Salt = "new_salt"
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.True(t, diff.ChangedCampaigns[0].AllocationChanged)
+
+	newConfig = createDiffTestConfig()
+	newConfig.Campaigns[0].BucketSpace = 1000
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.True(t, diff.ChangedCampaigns[0].AllocationChanged)
+
+	newConfig = createDiffTestConfig()
+	newConfig.Campaigns[0].BucketingKey = "company_id"
+	diff = DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.True(t, diff.ChangedCampaigns[0].AllocationChanged)
+}
+
+func TestDiffConfigurationsPriority(t *testing.T) {
+	newConfig := createDiffTestConfig()
+	newConfig.Campaigns[1].Priority = 10
+
+	diff := DiffConfigurations(createDiffTestConfig(), newConfig)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.Equal(t, "c2", diff.ChangedCampaigns[0].ID)
+	assert.True(t, diff.ChangedCampaigns[0].PriorityChanged)
+	assert.False(t, diff.ChangedCampaigns[0].AllocationChanged)
+}
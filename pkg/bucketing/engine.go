@@ -3,30 +3,51 @@ package bucketing
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/abtasty/flagship-go-sdk/pkg/utils"
 
 	"github.com/abtasty/flagship-go-sdk/pkg/decision"
 	"github.com/abtasty/flagship-go-sdk/pkg/logging"
+	"golang.org/x/crypto/ed25519"
 )
 
 var logger = logging.GetLogger("Bucketing Engine")
 
 // Engine represents a bucketing engine
 type Engine struct {
-	pollingInterval  time.Duration
-	config           *Configuration
-	apiClient        ConfigAPIInterface
-	apiClientOptions []func(*APIClient)
-	envID            string
-	configMux        sync.Mutex
-	executionGroup   *utils.ExecGroup
-	ticker           *time.Ticker
+	// lastSuccessfulLoad is accessed atomically and kept first for 64-bit alignment on 32-bit platforms
+	lastSuccessfulLoad  int64
+	pollingInterval     time.Duration
+	pollingMaxBackoff   time.Duration
+	config              *Configuration
+	evaluators          map[*VariationGroup]targetingEvaluator
+	counters            *statsCounters
+	apiClient           ConfigAPIInterface
+	apiClientOptions    []func(*APIClient)
+	envID               string
+	configMux           sync.Mutex
+	notifyMux           sync.Mutex
+	executionGroup      *utils.ExecGroup
+	pollingStarted      int32
+	changeListeners     []func(*ConfigurationDiff)
+	streamURL           string
+	streamMinBackoff    time.Duration
+	streamMaxBackoff    time.Duration
+	streamConnected     int32
+	cacheDir            string
+	now                 func() time.Time
+	bucketingKey        string
+	signatureKey        ed25519.PublicKey
+	consecutiveFailures int32
+	random              *rand.Rand
 }
 
-// PollingInterval sets the polling interval for the bucketing engine
+// PollingInterval sets the polling interval for the bucketing engine. -1 disables polling, other intervals are at least one second
 func PollingInterval(interval time.Duration) func(r *Engine) {
 	return func(r *Engine) {
 		r.pollingInterval = interval
@@ -40,20 +61,52 @@ func APIOptions(apiOptions ...func(*APIClient)) func(r *Engine) {
 	}
 }
 
+// DefaultBucketingKey sets the context key used to allocate visitors in campaigns that do not set their own bucketing key
+func DefaultBucketingKey(key string) func(r *Engine) {
+	return func(r *Engine) {
+		r.bucketingKey = key
+	}
+}
+
+// OnConfigurationChange registers a listener called with the diff each time a loaded configuration differs from the previous one.
+// Listeners are called one at a time, in the order the configurations are swapped, and must not load the configuration themselves
+func OnConfigurationChange(listener func(diff *ConfigurationDiff)) func(r *Engine) {
+	return func(r *Engine) {
+		r.changeListeners = append(r.changeListeners, listener)
+	}
+}
+
 // NewEngine creates a new engine for bucketing
 func NewEngine(envID string, eg *utils.ExecGroup, params ...func(*Engine)) (*Engine, error) {
 	engine := &Engine{
-		pollingInterval:  1 * time.Minute,
-		envID:            envID,
-		apiClientOptions: []func(*APIClient){},
-		executionGroup:   eg,
+		pollingInterval:   1 * time.Minute,
+		pollingMaxBackoff: defaultPollingMaxBackoff,
+		envID:             envID,
+		apiClientOptions:  []func(*APIClient){},
+		executionGroup:    eg,
+		streamMinBackoff:  defaultStreamMinBackoff,
+		streamMaxBackoff:  defaultStreamMaxBackoff,
+		now:               time.Now,
+		random:            rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 
 	for _, param := range params {
 		param(engine)
 	}
 
-	engine.apiClient = NewAPIClient(envID, engine.apiClientOptions...)
+	if engine.pollingInterval != -1 && engine.pollingInterval < minPollingInterval {
+		logger.Warning(fmt.Sprintf("Polling interval %v is too short, using %v", engine.pollingInterval, minPollingInterval))
+		engine.pollingInterval = minPollingInterval
+	}
+
+	if engine.apiClient == nil {
+		engine.apiClient = NewAPIClient(envID, engine.apiClientOptions...)
+	}
+
+	cacheErr := engine.loadCache()
+	if cacheErr != nil && !os.IsNotExist(cacheErr) {
+		logger.Warning(fmt.Sprintf("Configuration cache could not be loaded : %v", cacheErr))
+	}
 
 	err := engine.Load()
 
@@ -61,21 +114,47 @@ func NewEngine(envID string, eg *utils.ExecGroup, params ...func(*Engine)) (*Eng
 		engine.executionGroup.Go(engine.startTicker)
 	}
 
+	if engine.streamURL != "" {
+		engine.executionGroup.Go(engine.startStream)
+	}
+
+	if watcher, ok := engine.apiClient.(ConfigSourceWatcher); ok {
+		engine.executionGroup.Go(func(ctx context.Context) {
+			watcher.Watch(ctx, func() {
+				engine.Load()
+			})
+		})
+	}
+
 	return engine, err
 }
 
-// startTicker starts new ticker for flushing hits
+// startTicker polls the env configuration after a random initial delay, backing off after consecutive failures
 func (b *Engine) startTicker(ctx context.Context) {
-	if b.ticker != nil {
+	if !atomic.CompareAndSwapInt32(&b.pollingStarted, 0, 1) {
 		return
 	}
-	b.ticker = time.NewTicker(b.pollingInterval)
+
+	timer := time.NewTimer(initialPollingDelay(b.pollingInterval, b.random))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-b.ticker.C:
+		case <-timer.C:
+			if b.IsStreaming() {
+				logger.Debug("Bucketing stream connected, skipping polling")
+				timer.Reset(b.pollingInterval)
+				continue
+			}
 			logger.Info("Bucketing engine ticked, loading configuration")
 			b.Load()
+
+			failures := b.ConsecutiveFailures()
+			delay := nextPollingDelay(b.pollingInterval, b.pollingMaxBackoff, failures, b.random.Float64())
+			if failures > 0 {
+				logger.Warning(fmt.Sprintf("Configuration load failed %d time(s) in a row, next poll in %v", failures, delay))
+			}
+			timer.Reset(delay)
 		case <-ctx.Done():
 			logger.Info("Bucketing engine stopped")
 			return
@@ -85,29 +164,107 @@ func (b *Engine) startTicker(ctx context.Context) {
 
 // Load loads the env configuration in cache
 func (b *Engine) Load() error {
-	newConfig, err := b.apiClient.GetConfiguration()
+	var newConfig *Configuration
+	var payload, signature []byte
+	var err error
+
+	if b.signatureKey != nil {
+		newConfig, payload, signature, err = b.getSignedConfiguration()
+	} else {
+		newConfig, err = b.apiClient.GetConfiguration()
+	}
 
 	if err != nil {
 		logger.Error("Error when loading environment configuration", err)
+		b.recordLoad(err)
+		return err
+	}
+
+	if err := ValidateConfiguration(newConfig); err != nil {
+		logger.Error("Invalid environment configuration, keeping the previous one", err)
+		b.recordLoad(err)
 		return err
 	}
 
+	b.setConfiguration(newConfig)
+	if b.signatureKey != nil {
+		b.writeSignedCache(payload, signature)
+	} else {
+		b.writeCache(newConfig)
+	}
+	b.recordLoad(nil)
+
+	return nil
+}
+
+// setConfiguration compiles the env configuration targetings and swaps it in cache
+func (b *Engine) setConfiguration(newConfig *Configuration) {
+	evaluators := compileConfiguration(newConfig)
+
+	// The notify lock is held across the swap and the notification so that diffs are delivered one at a time and in order
+	b.notifyMux.Lock()
+	defer b.notifyMux.Unlock()
+
 	b.configMux.Lock()
+	oldConfig := b.config
 	b.config = newConfig
+	b.evaluators = evaluators
+	b.counters = newStatsCounters(newConfig, b.counters)
 	b.configMux.Unlock()
 
-	return nil
+	b.notifyChange(oldConfig, newConfig)
+}
+
+// getConfig returns the env configuration in cache
+func (b *Engine) getConfig() *Configuration {
+	b.configMux.Lock()
+	defer b.configMux.Unlock()
+	return b.config
+}
+
+// getCompiledConfig returns the env configuration in cache, its compiled targetings and its evaluation counters
+func (b *Engine) getCompiledConfig() (*Configuration, map[*VariationGroup]targetingEvaluator, *statsCounters) {
+	b.configMux.Lock()
+	defer b.configMux.Unlock()
+	return b.config, b.evaluators, b.counters
+}
+
+// targetingMatch evaluates the compiled targeting of a variation group, or the targeting itself if it has not been compiled
+func targetingMatch(evaluators map[*VariationGroup]targetingEvaluator, vg *VariationGroup, visitorID string, context map[string]interface{}, now time.Time) (bool, error) {
+	if evaluate, ok := evaluators[vg]; ok {
+		return evaluate(visitorID, context, now)
+	}
+	return targetingMatchAt(vg, visitorID, context, now)
+}
+
+// notifyChange publishes the configuration diff to the registered listeners
+func (b *Engine) notifyChange(oldConfig *Configuration, newConfig *Configuration) {
+	if len(b.changeListeners) == 0 {
+		return
+	}
+
+	diff := DiffConfigurations(oldConfig, newConfig)
+	if diff.IsEmpty() {
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Configuration changed : %d campaign(s) added, %d removed, %d changed", len(diff.AddedCampaigns), len(diff.RemovedCampaigns), len(diff.ChangedCampaigns)))
+	for _, listener := range b.changeListeners {
+		listener(diff)
+	}
 }
 
 // GetModifications gets modifications from Decision API
 func (b *Engine) GetModifications(visitorID string, context map[string]interface{}) (*decision.APIClientResponse, error) {
-	if b.config == nil {
+	config, evaluators, counters := b.getCompiledConfig()
+	if config == nil {
 		logger.Info("Configuration not loaded. Loading it now")
 		err := b.Load()
 		if err != nil {
 			logger.Warning("Configuration could not be loaded.")
 			return nil, err
 		}
+		config, evaluators, counters = b.getCompiledConfig()
 	}
 
 	resp := &decision.APIClientResponse{
@@ -115,46 +272,104 @@ func (b *Engine) GetModifications(visitorID string, context map[string]interface
 		Campaigns: []decision.APIClientCampaign{},
 	}
 
-	if b.config.Panic {
+	if config.Panic {
 		logger.Info("Environment is in panic mode. Skipping all campaigns")
 		return resp, nil
 	}
 
-	for _, c := range b.config.Campaigns {
+	now := b.now()
+	holdoutBucketingID := BucketingID(visitorID, &Campaign{}, context, b.bucketingKey)
+
+	resp.Holdout = inHoldout(config.Holdout, holdoutBucketingID)
+	if resp.Holdout {
+		logger.Debug(fmt.Sprintf("Visitor %s is held out. Only reference variations are returned", visitorID))
+	}
+
+	assignments := layerAssignments(config.Layers, config.Campaigns, func(c *Campaign) string {
+		return BucketingID(visitorID, c, context, b.bucketingKey)
+	})
+
+	for _, c := range config.Campaigns {
+		if !inSchedule(c.StartDate, c.EndDate, now) {
+			continue
+		}
+
+		campaignCounters := counters.campaign(c)
+		campaignCounters.addEvaluation()
+
+		if assigned, inLayer := assignments[c.ID]; inLayer && !assigned {
+			continue
+		}
+
+		bucketingID := BucketingID(visitorID, c, context, b.bucketingKey)
+		if !inTrafficAllocation(c, bucketingID) || !inRollout(c, bucketingID, now) {
+			continue
+		}
+
 		var matchedVg *VariationGroup
 		for _, vg := range c.VariationGroups {
-			matched, err := TargetingMatch(vg, visitorID, context)
+			if !inSchedule(vg.StartDate, vg.EndDate, now) {
+				continue
+			}
+
+			vgCounters := counters.variationGroup(vg)
+			vgCounters.addEvaluation()
+
+			matched, err := targetingMatch(evaluators, vg, visitorID, context, now)
 			if err != nil {
 				logger.Warning(fmt.Sprintf("Error occurred when checking targeting : %v", err))
 				continue
 			}
 
 			if matched {
+				vgCounters.addMatch()
 				matchedVg = vg
 				break
 			}
 		}
 
-		if matchedVg != nil {
-			variation, err := GetRandomAllocation(visitorID, matchedVg)
-			if err != nil {
-				logger.Warning(fmt.Sprintf("Error occurred when allocating variation : %v", err))
-				continue
-			}
-			campaign := decision.APIClientCampaign{
-				ID:               c.ID,
-				VariationGroupID: matchedVg.ID,
-				Variation: decision.APIClientVariation{
-					ID:        variation.ID,
-					Reference: variation.Reference,
-					Modifications: decision.APIClientModification{
-						Type:  variation.Modifications.Type,
-						Value: variation.Modifications.Value,
-					},
-				},
+		if matchedVg == nil {
+			continue
+		}
+		campaignCounters.addMatch()
+
+		// Held out visitors are never exposed to an experiment, they get the reference variation if there is one
+		if resp.Holdout {
+			campaignCounters.addHoldout()
+			if variation := referenceVariation(matchedVg); variation != nil {
+				resp.Campaigns = append(resp.Campaigns, newDecisionCampaign(c, matchedVg, variation, true))
 			}
-			resp.Campaigns = append(resp.Campaigns, campaign)
+			continue
 		}
+
+		variation, err := GetCampaignAllocation(bucketingID, c, matchedVg)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Error occurred when allocating variation : %v", err))
+			continue
+		}
+		campaignCounters.addAssignment()
+		counters.variationGroup(matchedVg).addAssignment()
+		counters.variation(variation).addAssignment()
+		resp.Campaigns = append(resp.Campaigns, newDecisionCampaign(c, matchedVg, variation, false))
 	}
 	return resp, nil
 }
+
+// newDecisionCampaign returns the decision of a campaign for the allocated variation
+func newDecisionCampaign(c *Campaign, vg *VariationGroup, variation *Variation, holdout bool) decision.APIClientCampaign {
+	return decision.APIClientCampaign{
+		ID:               c.ID,
+		VariationGroupID: vg.ID,
+		Type:             c.Type,
+		Priority:         c.Priority,
+		Holdout:          holdout,
+		Variation: decision.APIClientVariation{
+			ID:        variation.ID,
+			Reference: variation.Reference,
+			Modifications: decision.APIClientModification{
+				Type:  variation.Modifications.Type,
+				Value: variation.Modifications.Value,
+			},
+		},
+	}
+}
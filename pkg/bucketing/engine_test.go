@@ -2,8 +2,11 @@ package bucketing
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -143,43 +146,63 @@ func TestPanic(t *testing.T) {
 	assert.Equal(t, 0, len(modifs.Campaigns))
 }
 
-func TestPollingPanic(t *testing.T) {
-	eg := utils.NewExecGroup(context.Background())
-	engine, _ := NewEngine(testEnvID, eg, PollingInterval(1*time.Second))
+// lockedAPIClientMock is a configuration source whose configuration can be replaced while the engine polls it
+type lockedAPIClientMock struct {
+	mux    sync.Mutex
+	config *Configuration
+}
 
-	config := &Configuration{
+func (m *lockedAPIClientMock) GetConfiguration() (*Configuration, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.config, nil
+}
+
+func (m *lockedAPIClientMock) setConfiguration(config *Configuration) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.config = config
+}
+
+func TestPollingPanic(t *testing.T) {
+	source := &lockedAPIClientMock{config: &Configuration{
 		Campaigns: []*Campaign{{
 			ID: "test_cid",
 		}},
-	}
+	}}
 
-	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(1*time.Second), ConfigSource(source))
 	time.Sleep(1100 * time.Millisecond)
 
-	assert.Equal(t, 1, len(engine.config.Campaigns))
-	assert.Equal(t, false, engine.config.Panic)
+	assert.Equal(t, 1, len(engine.getConfig().Campaigns))
+	assert.Equal(t, false, engine.getConfig().Panic)
 
 	// Setting panic
-	config.Panic = true
+	source.setConfiguration(&Configuration{
+		Panic: true,
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+		}},
+	})
 
-	time.Sleep(1100 * time.Millisecond)
+	time.Sleep(2100 * time.Millisecond)
 
-	assert.Equal(t, 1, len(engine.config.Campaigns))
-	assert.Equal(t, true, engine.config.Panic)
+	assert.Equal(t, 1, len(engine.getConfig().Campaigns))
+	assert.Equal(t, true, engine.getConfig().Panic)
+	eg.TerminateAndWait()
 }
 
 func TestClose(t *testing.T) {
 	ctx := context.Background()
 	eg := utils.NewExecGroup(ctx)
-	engine, _ := NewEngine(testEnvID, eg, PollingInterval(1*time.Second))
-
 	config := &Configuration{
 		Campaigns: []*Campaign{{
 			ID: "test_cid",
 		}},
 	}
 
-	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+	NewEngine(testEnvID, eg, PollingInterval(1*time.Second), ConfigSource(NewAPIClientMock(testEnvID, config, 200)))
 	time.Sleep(1100 * time.Millisecond)
 
 	wg := &sync.WaitGroup{}
@@ -192,3 +215,104 @@ func TestClose(t *testing.T) {
 	eg.TerminateAndWait()
 	wg.Wait()
 }
+
+func TestOnConfigurationChange(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	diffs := []*ConfigurationDiff{}
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), OnConfigurationChange(func(diff *ConfigurationDiff) {
+		diffs = append(diffs, diff)
+	}))
+
+	config := &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+		}},
+	}
+
+	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+	engine.Load()
+
+	assert.Equal(t, 1, len(diffs))
+	assert.Equal(t, []string{"test_cid"}, diffs[0].AddedCampaigns)
+
+	// Same configuration does not publish a diff
+	engine.Load()
+	assert.Equal(t, 1, len(diffs))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{Panic: true}, 200)
+	engine.Load()
+
+	assert.Equal(t, 2, len(diffs))
+	assert.True(t, diffs[1].PanicChanged)
+	assert.Equal(t, []string{"test_cid"}, diffs[1].RemovedCampaigns)
+}
+
+func TestOnConfigurationChangeOrder(t *testing.T) {
+	var active int32
+	previous := ""
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), OnConfigurationChange(func(diff *ConfigurationDiff) {
+		if atomic.AddInt32(&active, 1) != 1 {
+			t.Errorf("Configuration change listeners called concurrently")
+		}
+		defer atomic.AddInt32(&active, -1)
+
+		// Each diff starts from the configuration of the previous one
+		removed := ""
+		if len(diff.RemovedCampaigns) > 0 {
+			removed = diff.RemovedCampaigns[0]
+		}
+		if removed != previous {
+			t.Errorf("Configuration change delivered out of order : removed %s, expected %s", removed, previous)
+		}
+		previous = diff.AddedCampaigns[0]
+		time.Sleep(time.Millisecond)
+	}))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			engine.setConfiguration(&Configuration{Campaigns: []*Campaign{{ID: strconv.Itoa(i)}}})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, engine.getConfig().Campaigns[0].ID, previous)
+}
+
+func TestBucketingKey(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	config := &Configuration{
+		Campaigns: []*Campaign{{
+			ID:                "test_cid",
+			AllocationVersion: AllocationV2,
+			BucketingKey:      "company_id",
+			VariationGroups: []*VariationGroup{{
+				ID: "test_vgid",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: []*Targeting{{
+							Operator: EXISTS,
+							Key:      "company_id",
+						}},
+					}},
+				},
+				Variations: []*Variation{{ID: "1", Allocation: 50}, {ID: "2", Allocation: 50}},
+			}},
+		}},
+	}
+	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+
+	// All the visitors of a company get the same variation
+	variations := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		modifs, err := engine.GetModifications(fmt.Sprintf("vid_%d", i), map[string]interface{}{"company_id": "acme"})
+		assert.Nil(t, err)
+		variations[modifs.Campaigns[0].Variation.ID] = true
+	}
+	assert.Equal(t, 1, len(variations))
+}
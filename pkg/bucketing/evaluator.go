@@ -0,0 +1,325 @@
+package bucketing
+
+import (
+	"strings"
+	"time"
+)
+
+// targetingEvaluator evaluates a compiled variation group targeting for a visitor at the current time now
+type targetingEvaluator func(visitorID string, context map[string]interface{}, now time.Time) (bool, error)
+
+// valueEvaluator evaluates a compiled targeting against a context value
+type valueEvaluator func(contextValue interface{}) (bool, error)
+
+// compiledTargeting represents a targeting whose value has been parsed once for all visitors
+type compiledTargeting struct {
+	key      string
+	operator TargetingOperator
+	negative bool
+	match    valueEvaluator
+}
+
+// compileConfiguration compiles the targeting of every variation group of the configuration
+func compileConfiguration(config *Configuration) map[*VariationGroup]targetingEvaluator {
+	evaluators := map[*VariationGroup]targetingEvaluator{}
+	if config == nil {
+		return evaluators
+	}
+
+	for _, c := range config.Campaigns {
+		if c == nil {
+			continue
+		}
+		for _, vg := range c.VariationGroups {
+			if vg != nil {
+				evaluators[vg] = compileVariationGroup(vg)
+			}
+		}
+	}
+	return evaluators
+}
+
+// compileVariationGroup compiles the targeting of a variation group into an evaluator that returns the same result as targetingMatchAt
+// without using reflection for the value types sent by the SDK
+func compileVariationGroup(vg *VariationGroup) targetingEvaluator {
+	groups := [][]*compiledTargeting{}
+	for _, targetingGroup := range vg.Targeting.TargetingGroups {
+		group := []*compiledTargeting{}
+		for _, targeting := range targetingGroup.Targetings {
+			group = append(group, compileTargeting(targeting))
+		}
+		groups = append(groups, group)
+	}
+
+	return func(visitorID string, context map[string]interface{}, now time.Time) (bool, error) {
+		globalMatch := false
+		for _, group := range groups {
+			matchGroup := len(group) > 0
+			for _, targeting := range group {
+				v, ok := context[targeting.key]
+				switch targeting.key {
+				case "fs_all_users":
+					continue
+				case "fs_users":
+					v = visitorID
+					ok = true
+				case CurrentTimeContextKey:
+					if !ok && !now.IsZero() {
+						v = currentTimeValue(targeting.operator, now)
+						ok = true
+					}
+				}
+
+				matchTargeting, err := targeting.evaluate(v, ok)
+				if err != nil {
+					return false, err
+				}
+
+				matchGroup = matchGroup && matchTargeting
+			}
+			globalMatch = globalMatch || matchGroup
+		}
+		return globalMatch, nil
+	}
+}
+
+// evaluate matches the compiled targeting against a context value that may be missing, like targetingMatchPresence
+func (t *compiledTargeting) evaluate(contextValue interface{}, exists bool) (bool, error) {
+	exists = exists && contextValue != nil
+
+	switch t.operator {
+	case EXISTS:
+		return exists, nil
+	case NOT_EXISTS, NULL:
+		return !exists, nil
+	}
+
+	if !exists {
+		return t.negative, nil
+	}
+
+	return t.match(contextValue)
+}
+
+// compileTargeting parses the targeting value once. Values that cannot be compiled are evaluated with targetingMatchValue
+func compileTargeting(targeting *Targeting) *compiledTargeting {
+	compiled := &compiledTargeting{
+		key:      targeting.Key,
+		operator: targeting.Operator,
+		negative: isNegativeOperator(targeting.Operator),
+	}
+
+	switch {
+	case isDateOperator(targeting.Operator):
+		compiled.match = compileDateEvaluator(targeting)
+	case isVersionTargeting(targeting):
+		compiled.match = compileVersionEvaluator(targeting)
+	default:
+		compiled.match = compileOperatorEvaluator(targeting.Operator, targeting.Value)
+	}
+
+	if compiled.match == nil {
+		compiled.match = func(contextValue interface{}) (bool, error) {
+			return targetingMatchValue(targeting, contextValue)
+		}
+	}
+	return compiled
+}
+
+// compileDateEvaluator compiles a date targeting, nil if its value or time zone is invalid
+func compileDateEvaluator(targeting *Targeting) valueEvaluator {
+	loc, err := loadTimeZone(targeting.TimeZone)
+	if err != nil {
+		return nil
+	}
+
+	matchTime, err := compileDateMatcher(targeting.Operator, targeting.Value, loc)
+	if err != nil {
+		return nil
+	}
+
+	return func(contextValue interface{}) (bool, error) {
+		contextTime, err := parseTime(contextValue, loc)
+		if err != nil {
+			return false, err
+		}
+		return matchTime(contextTime), nil
+	}
+}
+
+// compileVersionEvaluator compiles a version targeting, nil if its value is not a valid version.
+// Context values that are not versions fall back to string comparison unless the targeting type is version
+func compileVersionEvaluator(targeting *Targeting) valueEvaluator {
+	targetingValue, ok := targeting.Value.(string)
+	if !ok {
+		return compileOperatorEvaluator(targeting.Operator, targeting.Value)
+	}
+
+	var matchVersion func(contextVersion *Version) (bool, error)
+	if targeting.Operator == VERSION_RANGE {
+		if _, err := versionMatchRange(targetingValue, &Version{}); err != nil {
+			return nil
+		}
+		matchVersion = func(contextVersion *Version) (bool, error) {
+			return versionMatchRange(targetingValue, contextVersion)
+		}
+	} else {
+		targetingVersion, err := ParseVersion(targetingValue)
+		if err != nil {
+			return nil
+		}
+		matchVersion = func(contextVersion *Version) (bool, error) {
+			return versionMatchOperator(targeting.Operator, targetingVersion, contextVersion)
+		}
+	}
+
+	fallback := compileOperatorEvaluator(targeting.Operator, targeting.Value)
+	return func(contextValue interface{}) (bool, error) {
+		contextValueString, ok := contextValue.(string)
+		if !ok {
+			return fallback(contextValue)
+		}
+
+		contextVersion, err := ParseVersion(contextValueString)
+		if err != nil {
+			if targeting.Type == TargetingTypeVersion {
+				return false, err
+			}
+			return fallback(contextValue)
+		}
+
+		match, err := matchVersion(contextVersion)
+		if err == nil || targeting.Type == TargetingTypeVersion {
+			return match, err
+		}
+		return fallback(contextValue)
+	}
+}
+
+// compileOperatorEvaluator compiles an operator and targeting value into an evaluator that returns the same result as targetingMatchOperator
+func compileOperatorEvaluator(operator TargetingOperator, targetingValue interface{}) valueEvaluator {
+	switch operator {
+	case IN, NOT_IN:
+		if _, ok := takeSliceArg(targetingValue); !ok {
+			targetingValue = []interface{}{targetingValue}
+		}
+		operator = map[TargetingOperator]TargetingOperator{IN: EQUALS, NOT_IN: NOT_EQUALS}[operator]
+	}
+
+	matchScalar := compileScalarEvaluator(operator, targetingValue)
+
+	var match valueEvaluator
+	match = func(contextValue interface{}) (bool, error) {
+		// List context values are converted to []interface{} by the SDK, other slice types use the reflection path
+		if contextList, ok := contextValue.([]interface{}); ok {
+			return targetingMatchList(operator, contextList, match), nil
+		}
+		return matchScalar(contextValue)
+	}
+	return match
+}
+
+// compileScalarEvaluator compiles a targeting value, or each value of a targeting list, for a context value that is not a list
+func compileScalarEvaluator(operator TargetingOperator, targetingValue interface{}) valueEvaluator {
+	dynamic := func(contextValue interface{}) (bool, error) {
+		return targetingMatchOperator(operator, targetingValue, contextValue)
+	}
+
+	if targetingList, ok := takeSliceArg(targetingValue); ok {
+		negative := isNegativeOperator(operator)
+		evaluators := make([]valueEvaluator, len(targetingList))
+		for i, v := range targetingList {
+			evaluators[i] = compileOperatorEvaluator(operator, v)
+		}
+		return func(contextValue interface{}) (bool, error) {
+			switch contextValue.(type) {
+			case string, float64, bool:
+				return matchEvaluators(negative, evaluators, contextValue), nil
+			}
+			return dynamic(contextValue)
+		}
+	}
+
+	switch tv := targetingValue.(type) {
+	case string:
+		return compileStringEvaluator(operator, tv, dynamic)
+	case float64:
+		return func(contextValue interface{}) (bool, error) {
+			if cv, ok := contextValue.(float64); ok {
+				return targetingMatchOperatorNumber(operator, tv, cv)
+			}
+			return dynamic(contextValue)
+		}
+	case bool:
+		return func(contextValue interface{}) (bool, error) {
+			if cv, ok := contextValue.(bool); ok {
+				return targetingMatchOperatorBool(operator, tv, cv)
+			}
+			return dynamic(contextValue)
+		}
+	}
+	return dynamic
+}
+
+// compileStringEvaluator compiles a string targeting value, lowering it once for case insensitive operators and compiling regex patterns
+func compileStringEvaluator(operator TargetingOperator, targetingValue string, dynamic valueEvaluator) valueEvaluator {
+	if caseSensitiveOperator, ok := caseSensitiveOperators[operator]; ok {
+		return func(contextValue interface{}) (bool, error) {
+			if cv, ok := contextValue.(string); ok {
+				return targetingMatchOperatorStringCase(caseSensitiveOperator, targetingValue, cv)
+			}
+			return dynamic(contextValue)
+		}
+	}
+
+	// Regex patterns are compiled once per targeting, invalid ones are evaluated dynamically to report their error
+	if operator == REGEX || operator == NOT_REGEX {
+		if re, err := newRegex(targetingValue); err == nil {
+			negative := operator == NOT_REGEX
+			return func(contextValue interface{}) (bool, error) {
+				if cv, ok := contextValue.(string); ok {
+					match, err := compiledRegexMatch(re, cv)
+					if negative {
+						return !match && err == nil, err
+					}
+					return match, err
+				}
+				return dynamic(contextValue)
+			}
+		}
+	}
+
+	switch operator {
+	case REGEX, NOT_REGEX, VERSION_EQUALS, VERSION_NOT_EQUALS, VERSION_LOWER_THAN, VERSION_LOWER_THAN_OR_EQUALS,
+		VERSION_GREATER_THAN, VERSION_GREATER_THAN_OR_EQUALS, VERSION_RANGE:
+		return func(contextValue interface{}) (bool, error) {
+			if cv, ok := contextValue.(string); ok {
+				return targetingMatchOperatorString(operator, targetingValue, cv)
+			}
+			return dynamic(contextValue)
+		}
+	}
+
+	lowerTargetingValue := strings.ToLower(targetingValue)
+	return func(contextValue interface{}) (bool, error) {
+		if cv, ok := contextValue.(string); ok {
+			return targetingMatchOperatorStringCase(operator, lowerTargetingValue, strings.ToLower(cv))
+		}
+		return dynamic(contextValue)
+	}
+}
+
+// matchEvaluators matches a context value against the evaluators of each value of a targeting list like targetingMatchList
+func matchEvaluators(negative bool, evaluators []valueEvaluator, contextValue interface{}) bool {
+	for _, match := range evaluators {
+		subValueMatch, err := match(contextValue)
+		subValueMatch = err == nil && subValueMatch
+		if negative && !subValueMatch {
+			return false
+		}
+		if !negative && subValueMatch {
+			return true
+		}
+	}
+	return negative
+}
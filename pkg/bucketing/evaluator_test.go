@@ -0,0 +1,152 @@
+package bucketing
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var evaluatorTestTargetings = []*Targeting{
+	{Operator: EQUALS, Key: "string", Value: "Abc"},
+	{Operator: NOT_EQUALS, Key: "string", Value: "abc"},
+	{Operator: CONTAINS, Key: "string", Value: "B"},
+	{Operator: STARTS_WITH_CASE_SENSITIVE, Key: "string", Value: "A"},
+	{Operator: REGEX, Key: "string", Value: "^a.c$"},
+	{Operator: NOT_REGEX, Key: "string", Value: "^a(+$"},
+	{Operator: NOT_REGEX, Key: "string", Value: "^A"},
+	{Operator: GREATER_THAN, Key: "number", Value: 10.0},
+	{Operator: CONTAINS, Key: "number", Value: 10.0},
+	{Operator: EQUALS, Key: "bool", Value: true},
+	{Operator: IN, Key: "string", Value: []interface{}{"abc", "def"}},
+	{Operator: NOT_IN, Key: "number", Value: []interface{}{1.0, 42.0}},
+	{Operator: EQUALS, Key: "list", Value: "b"},
+	{Operator: NOT_CONTAINS, Key: "list", Value: []interface{}{"x", "y"}},
+	{Operator: EXISTS, Key: "number"},
+	{Operator: NOT_EXISTS, Key: "missing"},
+	{Operator: NOT_EQUALS, Key: "missing", Value: "abc"},
+	{Operator: EQUALS, Key: "fs_users", Value: "visitor_id"},
+	{Operator: VERSION_GREATER_THAN, Key: "app_version", Value: "1.2.0"},
+	{Operator: GREATER_THAN, Key: "app_version", Value: "1.10.0"},
+	{Operator: VERSION_RANGE, Key: "app_version", Value: ">=1.0.0 <2.0.0"},
+	{Operator: EQUALS, Key: "os_version", Value: "not a version"},
+	{Operator: EQUALS, Key: "build", Value: "1.2.3", Type: TargetingTypeVersion},
+	{Operator: DATE_AFTER, Key: CurrentTimeContextKey, Value: "2020-01-01"},
+	{Operator: DAY_OF_WEEK, Key: CurrentTimeContextKey, Value: []interface{}{"sat", "sun"}, TimeZone: "Europe/Paris"},
+	{Operator: HOUR_OF_DAY, Key: CurrentTimeContextKey, Value: "22-6"},
+	{Operator: DATE_BEFORE, Key: CurrentTimeContextKey, Value: "invalid"},
+}
+
+var evaluatorTestContexts = []map[string]interface{}{
+	{},
+	{"string": "abc", "number": 42.0, "bool": true, "list": []interface{}{"a", "b"}, "app_version": "1.2.3", "build": "1.2.3", CurrentTimeContextKey: "2020-06-06T23:00:00Z"},
+	{"string": "ABC", "number": 10.0, "bool": false, "list": []interface{}{"x"}, "app_version": "1.10.0", "os_version": "not a version", "build": "abc", CurrentTimeContextKey: "2019-06-05T12:00:00Z"},
+	{"string": 42.0, "number": "42", "bool": "true", "list": []string{"a", "b"}, "app_version": 1.0, "build": 1.0, CurrentTimeContextKey: 1591484400.0},
+	{"string": []interface{}{"def", 1.0}, "number": []interface{}{1.0, 2.0}, "list": "b", "app_version": "abc", CurrentTimeContextKey: "invalid"},
+}
+
+var evaluatorTestNow = time.Date(2020, 6, 6, 23, 0, 0, 0, time.UTC)
+
+func TestCompiledTargetingMatch(t *testing.T) {
+	for _, targeting := range evaluatorTestTargetings {
+		vg := &VariationGroup{
+			Targeting: TargetingWrapper{
+				TargetingGroups: []*TargetingGroup{{Targetings: []*Targeting{targeting}}},
+			},
+		}
+		evaluate := compileVariationGroup(vg)
+
+		for _, context := range evaluatorTestContexts {
+			match, err := targetingMatchAt(vg, "visitor_id", context, evaluatorTestNow)
+			compiledMatch, compiledErr := evaluate("visitor_id", context, evaluatorTestNow)
+
+			if match != compiledMatch || (err == nil) != (compiledErr == nil) {
+				t.Errorf("Compiled targeting %v differs for context %v - match : %v, compiled match : %v, err : %v, compiled err : %v", targeting, context, match, compiledMatch, err, compiledErr)
+			}
+		}
+	}
+}
+
+func TestCompiledRegex(t *testing.T) {
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{Targetings: []*Targeting{{Operator: REGEX, Key: "string", Value: "^compiled_[0-9]+$"}}}},
+		},
+	}
+	evaluate := compileVariationGroup(vg)
+
+	match, err := evaluate("visitor_id", map[string]interface{}{"string": "compiled_42"}, evaluatorTestNow)
+	if err != nil || !match {
+		t.Errorf("Compiled regex targeting should match - match : %v, err : %v", match, err)
+	}
+
+	_, err = evaluate("visitor_id", map[string]interface{}{"string": strings.Repeat("a", maxRegexInputLength+1)}, evaluatorTestNow)
+	if err == nil {
+		t.Errorf("Compiled regex targeting should reject too long context values")
+	}
+}
+
+func TestCompiledConfiguration(t *testing.T) {
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{Targetings: []*Targeting{{Operator: EQUALS, Key: "string", Value: "abc"}}}},
+		},
+	}
+	evaluators := compileConfiguration(&Configuration{Campaigns: []*Campaign{{VariationGroups: []*VariationGroup{vg}}}})
+
+	if _, ok := evaluators[vg]; !ok {
+		t.Error("Variation group targeting should be compiled")
+	}
+
+	match, err := targetingMatch(evaluators, vg, "visitor_id", map[string]interface{}{"string": "ABC"}, evaluatorTestNow)
+	if !match || err != nil {
+		t.Errorf("Compiled targeting should match. Got %v, %v", match, err)
+	}
+
+	// Variation groups that are not compiled are evaluated directly
+	match, err = targetingMatch(map[*VariationGroup]targetingEvaluator{}, vg, "visitor_id", map[string]interface{}{"string": "ABC"}, evaluatorTestNow)
+	if !match || err != nil {
+		t.Errorf("Targeting should match. Got %v, %v", match, err)
+	}
+}
+
+func createBenchmarkTargetingVariationGroup() *VariationGroup {
+	return &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{
+				{Targetings: []*Targeting{
+					{Operator: EQUALS, Key: "country", Value: "fr"},
+					{Operator: GREATER_THAN, Key: "age", Value: 18.0},
+				}},
+				{Targetings: []*Targeting{
+					{Operator: IN, Key: "plan", Value: []interface{}{"premium", "business"}},
+					{Operator: CONTAINS, Key: "email", Value: "@abtasty.com"},
+				}},
+			},
+		},
+	}
+}
+
+var benchmarkTargetingContext = map[string]interface{}{
+	"country": "US",
+	"age":     32.0,
+	"plan":    "business",
+	"email":   "visitor@abtasty.com",
+}
+
+func BenchmarkTargetingMatch(b *testing.B) {
+	vg := createBenchmarkTargetingVariationGroup()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		TargetingMatch(vg, "test_visitor_id", benchmarkTargetingContext)
+	}
+}
+
+func BenchmarkCompiledTargetingMatch(b *testing.B) {
+	evaluate := compileVariationGroup(createBenchmarkTargetingVariationGroup())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		evaluate("test_visitor_id", benchmarkTargetingContext, evaluatorTestNow)
+	}
+}
@@ -0,0 +1,257 @@
+package bucketing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The different targeting explanation reasons
+const (
+	ReasonMatched      = "matched"
+	ReasonNotMatched   = "operator result"
+	ReasonMissingKey   = "missing key"
+	ReasonTypeMismatch = "type mismatch"
+	ReasonError        = "error"
+	ReasonAllUsers     = "all users"
+	ReasonPanic        = "panic mode"
+	ReasonSchedule     = "out of schedule"
+	ReasonLayer        = "not assigned in layer"
+	ReasonTraffic      = "out of traffic allocation"
+	ReasonRollout      = "not enrolled in rollout"
+	ReasonNoTargeting  = "no variation group matched"
+	ReasonNotAllocated = "not allocated"
+	ReasonAllocated    = "allocated"
+	ReasonHoldout      = "held out"
+)
+
+// Explanation explains the decision of the engine for a visitor
+type Explanation struct {
+	VisitorID string                 `json:"visitorId"`
+	Panic     bool                   `json:"panic"`
+	Holdout   bool                   `json:"holdout"`
+	Campaigns []*CampaignExplanation `json:"campaigns"`
+}
+
+// CampaignExplanation explains the decision of the engine for a campaign
+type CampaignExplanation struct {
+	ID                      string                       `json:"id"`
+	BucketingID             string                       `json:"bucketingId"`
+	Reason                  string                       `json:"reason"`
+	VariationGroups         []*VariationGroupExplanation `json:"variationGroups"`
+	MatchedVariationGroupID string                       `json:"matchedVariationGroupId,omitempty"`
+	VariationID             string                       `json:"variationId,omitempty"`
+}
+
+// VariationGroupExplanation explains the targeting and allocation of a variation group
+type VariationGroupExplanation struct {
+	ID              string                       `json:"id"`
+	Matched         bool                         `json:"matched"`
+	OutOfSchedule   bool                         `json:"outOfSchedule,omitempty"`
+	Error           string                       `json:"error,omitempty"`
+	TargetingGroups []*TargetingGroupExplanation `json:"targetingGroups"`
+	Bucket          int                          `json:"bucket"`
+	BucketSpace     int                          `json:"bucketSpace"`
+}
+
+// TargetingGroupExplanation explains the targetings of a targeting group
+type TargetingGroupExplanation struct {
+	Matched    bool                    `json:"matched"`
+	Targetings []*TargetingExplanation `json:"targetings"`
+}
+
+// TargetingExplanation explains the result of a single targeting
+type TargetingExplanation struct {
+	Key            string            `json:"key"`
+	Operator       TargetingOperator `json:"operator"`
+	TargetingValue interface{}       `json:"targetingValue"`
+	ContextValue   interface{}       `json:"contextValue"`
+	Matched        bool              `json:"matched"`
+	Reason         string            `json:"reason"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// Explain returns, for every campaign and variation group, which targetings matched or failed and why,
+// and the allocation bucket the visitor hashed into. It does not change the engine state, and returns an error
+// if no configuration is loaded yet
+func (b *Engine) Explain(visitorID string, context map[string]interface{}) (*Explanation, error) {
+	config := b.getConfig()
+	if config == nil {
+		return nil, errors.New("Configuration not loaded")
+	}
+
+	explanation := &Explanation{
+		VisitorID: visitorID,
+		Panic:     config.Panic,
+		Campaigns: []*CampaignExplanation{},
+	}
+
+	now := b.now()
+	holdoutBucketingID := BucketingID(visitorID, &Campaign{}, context, b.bucketingKey)
+	explanation.Holdout = inHoldout(config.Holdout, holdoutBucketingID)
+	assignments := layerAssignments(config.Layers, config.Campaigns, func(c *Campaign) string {
+		return BucketingID(visitorID, c, context, b.bucketingKey)
+	})
+
+	for _, c := range config.Campaigns {
+		bucketingID := BucketingID(visitorID, c, context, b.bucketingKey)
+		campaignExplanation := &CampaignExplanation{
+			ID:              c.ID,
+			BucketingID:     bucketingID,
+			VariationGroups: []*VariationGroupExplanation{},
+		}
+		explanation.Campaigns = append(explanation.Campaigns, campaignExplanation)
+
+		if config.Panic {
+			campaignExplanation.Reason = ReasonPanic
+			continue
+		}
+
+		if !inSchedule(c.StartDate, c.EndDate, now) {
+			campaignExplanation.Reason = ReasonSchedule
+			continue
+		}
+
+		if assigned, inLayer := assignments[c.ID]; inLayer && !assigned {
+			campaignExplanation.Reason = ReasonLayer
+			continue
+		}
+
+		if !inTrafficAllocation(c, bucketingID) {
+			campaignExplanation.Reason = ReasonTraffic
+			continue
+		}
+
+		if !inRollout(c, bucketingID, now) {
+			campaignExplanation.Reason = ReasonRollout
+			continue
+		}
+
+		var matchedVg *VariationGroup
+		for _, vg := range c.VariationGroups {
+			vgExplanation := explainVariationGroup(vg, visitorID, context, now)
+			if !inSchedule(vg.StartDate, vg.EndDate, now) {
+				vgExplanation.OutOfSchedule = true
+				vgExplanation.Matched = false
+			}
+			bucketSpace := c.BucketSpace
+			if bucketSpace <= 0 {
+				bucketSpace = defaultBucketSpace
+			}
+			vgExplanation.BucketSpace = bucketSpace
+			if hashed, err := allocationHash(bucketingID, c, vg); err == nil && bucketSpace <= maxBucketSpace {
+				vgExplanation.Bucket = int(hashed % uint32(bucketSpace))
+			}
+			campaignExplanation.VariationGroups = append(campaignExplanation.VariationGroups, vgExplanation)
+
+			if matchedVg == nil && vgExplanation.Matched {
+				matchedVg = vg
+			}
+		}
+
+		if matchedVg == nil {
+			campaignExplanation.Reason = ReasonNoTargeting
+			continue
+		}
+
+		campaignExplanation.MatchedVariationGroupID = matchedVg.ID
+		if explanation.Holdout {
+			campaignExplanation.Reason = ReasonHoldout
+			if variation := referenceVariation(matchedVg); variation != nil {
+				campaignExplanation.VariationID = variation.ID
+			}
+			continue
+		}
+
+		variation, err := GetCampaignAllocation(bucketingID, c, matchedVg)
+		if err != nil {
+			campaignExplanation.Reason = ReasonNotAllocated
+			continue
+		}
+
+		campaignExplanation.Reason = ReasonAllocated
+		campaignExplanation.VariationID = variation.ID
+	}
+
+	return explanation, nil
+}
+
+// explainVariationGroup explains the targeting of a variation group
+func explainVariationGroup(vg *VariationGroup, visitorID string, context map[string]interface{}, now time.Time) *VariationGroupExplanation {
+	vgExplanation := &VariationGroupExplanation{
+		ID:              vg.ID,
+		TargetingGroups: []*TargetingGroupExplanation{},
+	}
+
+	matched, err := targetingMatchAt(vg, visitorID, context, now)
+	vgExplanation.Matched = matched && err == nil
+	if err != nil {
+		vgExplanation.Error = err.Error()
+	}
+
+	for _, targetingGroup := range vg.Targeting.TargetingGroups {
+		groupExplanation := &TargetingGroupExplanation{
+			Matched:    len(targetingGroup.Targetings) > 0,
+			Targetings: []*TargetingExplanation{},
+		}
+
+		for _, targeting := range targetingGroup.Targetings {
+			targetingExplanation := explainTargeting(targeting, visitorID, context, now)
+			groupExplanation.Matched = groupExplanation.Matched && targetingExplanation.Matched
+			groupExplanation.Targetings = append(groupExplanation.Targetings, targetingExplanation)
+		}
+		vgExplanation.TargetingGroups = append(vgExplanation.TargetingGroups, groupExplanation)
+	}
+
+	return vgExplanation
+}
+
+// explainTargeting explains the result of a single targeting
+func explainTargeting(targeting *Targeting, visitorID string, context map[string]interface{}, now time.Time) *TargetingExplanation {
+	v, ok := context[targeting.Key]
+	switch targeting.Key {
+	case "fs_all_users":
+		return &TargetingExplanation{
+			Key:            targeting.Key,
+			Operator:       targeting.Operator,
+			TargetingValue: targeting.Value,
+			Matched:        true,
+			Reason:         ReasonAllUsers,
+		}
+	case "fs_users":
+		v = visitorID
+		ok = true
+	case CurrentTimeContextKey:
+		if !ok && !now.IsZero() {
+			v = currentTimeValue(targeting.Operator, now)
+			ok = true
+		}
+	}
+
+	explanation := &TargetingExplanation{
+		Key:            targeting.Key,
+		Operator:       targeting.Operator,
+		TargetingValue: targeting.Value,
+		ContextValue:   v,
+	}
+
+	matched, err := targetingMatchPresence(targeting, v, ok)
+	explanation.Matched = matched && err == nil
+
+	switch {
+	case err == errKindMismatch:
+		explanation.Reason = ReasonTypeMismatch
+		explanation.Error = fmt.Sprintf("targeting value is %T, context value is %T", targeting.Value, v)
+	case err != nil:
+		explanation.Reason = ReasonError
+		explanation.Error = err.Error()
+	case !ok || v == nil:
+		explanation.Reason = ReasonMissingKey
+	case matched:
+		explanation.Reason = ReasonMatched
+	default:
+		explanation.Reason = ReasonNotMatched
+	}
+
+	return explanation
+}
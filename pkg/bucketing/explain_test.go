@@ -0,0 +1,97 @@
+package bucketing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+			VariationGroups: []*VariationGroup{{
+				ID: "vg_country",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: []*Targeting{
+							{Operator: EQUALS, Key: "country", Value: "FR"},
+							{Operator: EQUALS, Key: "age", Value: 30.0},
+							{Operator: EQUALS, Key: "premium", Value: true},
+						},
+					}},
+				},
+				Variations: []*Variation{{ID: "1", Allocation: 100}},
+			}, {
+				ID: "vg_users",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: []*Targeting{
+							{Operator: EQUALS, Key: "fs_users", Value: testVID},
+						},
+					}},
+				},
+				Variations: []*Variation{{ID: "2", Allocation: 100}},
+			}},
+		}},
+	}, 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	explanation, err := engine.Explain(testVID, map[string]interface{}{"country": "UK", "age": "30"})
+	assert.Nil(t, err)
+	assert.Equal(t, testVID, explanation.VisitorID)
+	assert.Equal(t, 1, len(explanation.Campaigns))
+
+	campaign := explanation.Campaigns[0]
+	assert.Equal(t, ReasonAllocated, campaign.Reason)
+	assert.Equal(t, "vg_users", campaign.MatchedVariationGroupID)
+	assert.Equal(t, "2", campaign.VariationID)
+	assert.Equal(t, 2, len(campaign.VariationGroups))
+
+	vgCountry := campaign.VariationGroups[0]
+	assert.False(t, vgCountry.Matched)
+	assert.NotEmpty(t, vgCountry.Error)
+	assert.Equal(t, defaultBucketSpace, vgCountry.BucketSpace)
+	assert.True(t, vgCountry.Bucket >= 0 && vgCountry.Bucket < defaultBucketSpace)
+
+	targetings := vgCountry.TargetingGroups[0].Targetings
+	assert.Equal(t, ReasonNotMatched, targetings[0].Reason)
+	assert.Equal(t, "UK", targetings[0].ContextValue)
+	assert.Equal(t, ReasonTypeMismatch, targetings[1].Reason)
+	assert.Equal(t, ReasonMissingKey, targetings[2].Reason)
+	assert.False(t, vgCountry.TargetingGroups[0].Matched)
+
+	vgUsers := campaign.VariationGroups[1]
+	assert.True(t, vgUsers.Matched)
+	assert.Equal(t, ReasonMatched, vgUsers.TargetingGroups[0].Targetings[0].Reason)
+	assert.True(t, vgUsers.TargetingGroups[0].Matched)
+
+	explanation, err = engine.Explain("other_vid", map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, ReasonNoTargeting, explanation.Campaigns[0].Reason)
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{Panic: true, Campaigns: []*Campaign{{ID: "test_cid"}}}, 200)
+	engine.Load()
+
+	explanation, err = engine.Explain(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.True(t, explanation.Panic)
+	assert.Equal(t, ReasonPanic, explanation.Campaigns[0].Reason)
+}
+
+func TestExplainNotLoaded(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), ConfigSource(failingAPIClient{}))
+
+	// Explain does not load the configuration itself
+	explanation, err := engine.Explain(testVID, map[string]interface{}{})
+	assert.NotNil(t, err)
+	assert.Nil(t, explanation)
+	assert.Equal(t, 1, engine.ConsecutiveFailures())
+}
@@ -0,0 +1,48 @@
+package bucketing
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultHoldoutSalt is the hash scope of the holdout when it does not set its own salt
+const defaultHoldoutSalt = "holdout"
+
+// Holdout represents the global population of visitors that are never exposed to an experiment
+type Holdout struct {
+	Percentage float64 `json:"percentage"`
+	Salt       string  `json:"salt,omitempty"`
+}
+
+// inHoldout returns true if the visitor is part of the holdout population
+func inHoldout(holdout *Holdout, bucketingID string) bool {
+	if holdout == nil || holdout.Percentage <= 0 {
+		return false
+	}
+
+	salt := holdout.Salt
+	if salt == "" {
+		salt = defaultHoldoutSalt
+	}
+
+	z := float64(scopedHash(salt, bucketingID) % layerBucketSpace)
+	return z < math.Round(holdout.Percentage*layerBucketSpace/100)
+}
+
+// referenceVariation returns the reference variation of the variation group, nil if it has none
+func referenceVariation(variationGroup *VariationGroup) *Variation {
+	for _, v := range variationGroup.Variations {
+		if v.Reference {
+			return v
+		}
+	}
+	return nil
+}
+
+// ValidateHoldout checks that the holdout percentage is between 0 and 100
+func ValidateHoldout(holdout *Holdout) error {
+	if holdout.Percentage < 0 || holdout.Percentage > 100 {
+		return fmt.Errorf("Holdout percentage %v is not between 0 and 100", holdout.Percentage)
+	}
+	return nil
+}
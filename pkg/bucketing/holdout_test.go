@@ -0,0 +1,96 @@
+package bucketing
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/decision"
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInHoldout(t *testing.T) {
+	assert.False(t, inHoldout(nil, "vid"))
+	assert.False(t, inHoldout(&Holdout{Percentage: 0}, "vid"))
+	assert.True(t, inHoldout(&Holdout{Percentage: 100}, "vid"))
+
+	countTotal := 20000
+	count := 0
+	same := 0
+	for i := 0; i < countTotal; i++ {
+		vID := strconv.Itoa(i)
+		heldOut := inHoldout(&Holdout{Percentage: 10}, vID)
+		if heldOut {
+			count++
+		}
+		if heldOut == inHoldout(&Holdout{Percentage: 10, Salt: "other"}, vID) {
+			same++
+		}
+	}
+
+	ratio := float64(count) / float64(countTotal)
+	if math.Abs(ratio-0.1) > 0.01 {
+		t.Errorf("Problem with stats: ratio %f, correctRatio : %f", ratio, 0.1)
+	}
+
+	// Different salts hold out different visitors (0.9 * 0.9 + 0.1 * 0.1 = 82% of visitors have the same status)
+	sameRatio := float64(same) / float64(countTotal)
+	if math.Abs(sameRatio-0.82) > 0.02 {
+		t.Errorf("Problem with stats: same ratio %f, correctRatio : %f", sameRatio, 0.82)
+	}
+}
+
+func TestEngineHoldout(t *testing.T) {
+	newCampaign := func(id string, reference bool) *Campaign {
+		return &Campaign{
+			ID: id,
+			VariationGroups: []*VariationGroup{{
+				ID: id + "_vgid",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: []*Targeting{{Operator: EQUALS, Key: "fs_all_users", Value: true}},
+					}},
+				},
+				Variations: []*Variation{
+					{ID: "reference", Allocation: 0, Reference: reference, Modifications: decision.APIClientModification{Value: map[string]interface{}{"flag": "reference"}}},
+					{ID: "variation", Allocation: 100, Modifications: decision.APIClientModification{Value: map[string]interface{}{"flag": "variation"}}},
+				},
+			}},
+		}
+	}
+	config := &Configuration{
+		Holdout:   &Holdout{Percentage: 100},
+		Campaigns: []*Campaign{newCampaign("with_reference", true), newCampaign("without_reference", false)},
+	}
+
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	modifs, err := engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.True(t, modifs.Holdout)
+	assert.Equal(t, 1, len(modifs.Campaigns))
+	assert.Equal(t, "with_reference", modifs.Campaigns[0].ID)
+	assert.Equal(t, "reference", modifs.Campaigns[0].Variation.ID)
+	assert.True(t, modifs.Campaigns[0].Holdout)
+
+	explanation, _ := engine.Explain(testVID, map[string]interface{}{})
+	assert.True(t, explanation.Holdout)
+	assert.Equal(t, ReasonHoldout, explanation.Campaigns[0].Reason)
+	assert.Equal(t, "reference", explanation.Campaigns[0].VariationID)
+
+	config.Holdout.Percentage = 0
+	modifs, err = engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.False(t, modifs.Holdout)
+	assert.Equal(t, 2, len(modifs.Campaigns))
+	assert.Equal(t, "variation", modifs.Campaigns[0].Variation.ID)
+	assert.False(t, modifs.Campaigns[0].Holdout)
+
+	assert.NotNil(t, ValidateConfiguration(&Configuration{Holdout: &Holdout{Percentage: 120}}))
+}
@@ -0,0 +1,99 @@
+package bucketing
+
+import (
+	"fmt"
+	"math"
+)
+
+// layerBucketSpace is the number of buckets visitors are hashed into for layers and traffic allocation
+const layerBucketSpace = 10000
+
+// trafficSalt is the hash scope prefix of the campaign traffic allocation
+const trafficSalt = "traffic"
+
+// Layer represents a group of mutually exclusive campaigns. Each visitor is hashed into at most one of them
+type Layer struct {
+	ID          string             `json:"id"`
+	Salt        string             `json:"salt,omitempty"`
+	Allocations []*LayerAllocation `json:"allocations"`
+}
+
+// LayerAllocation represents the percentage of visitors of a layer that can enter a campaign
+type LayerAllocation struct {
+	CampaignID string  `json:"campaignId"`
+	Allocation float64 `json:"allocation"`
+}
+
+// layerAssignments returns, for each campaign belonging to a layer, whether the visitor is hashed into it.
+// A layer is hashed with the bucketing ID of its campaigns, which all share the same bucketing key
+func layerAssignments(layers []*Layer, campaigns []*Campaign, bucketingID func(c *Campaign) string) map[string]bool {
+	if len(layers) == 0 {
+		return nil
+	}
+
+	campaignsByID := make(map[string]*Campaign, len(campaigns))
+	for _, c := range campaigns {
+		if c != nil {
+			campaignsByID[c.ID] = c
+		}
+	}
+
+	assignments := map[string]bool{}
+	for _, l := range layers {
+		scope := l.Salt
+		if scope == "" {
+			scope = l.ID
+		}
+		z := float64(scopedHash(scope, bucketingID(layerCampaign(l, campaignsByID))) % layerBucketSpace)
+
+		summedAlloc := 0.0
+		assigned := false
+		for _, a := range l.Allocations {
+			summedAlloc += a.Allocation
+			bound := math.Round(summedAlloc * layerBucketSpace / 100)
+			inCampaign := !assigned && z < bound
+			assigned = assigned || inCampaign
+
+			// A campaign in several layers must be assigned in all of them
+			previous, ok := assignments[a.CampaignID]
+			assignments[a.CampaignID] = inCampaign && (previous || !ok)
+		}
+	}
+	return assignments
+}
+
+// layerCampaign returns the first campaign of the layer found in the configuration, an empty campaign if there is none
+func layerCampaign(layer *Layer, campaignsByID map[string]*Campaign) *Campaign {
+	for _, a := range layer.Allocations {
+		if c, ok := campaignsByID[a.CampaignID]; ok {
+			return c
+		}
+	}
+	return &Campaign{}
+}
+
+// inTrafficAllocation returns true if the visitor is part of the share of eligible visitors entering the campaign
+func inTrafficAllocation(campaign *Campaign, bucketingID string) bool {
+	if campaign.TrafficAllocation == nil {
+		return true
+	}
+
+	z := float64(scopedHash(trafficSalt+campaign.ID, bucketingID) % layerBucketSpace)
+	return z < math.Round(*campaign.TrafficAllocation*layerBucketSpace/100)
+}
+
+// ValidateLayer checks that the layer allocations are positive and do not exceed 100% in total
+func ValidateLayer(layer *Layer) error {
+	summedAlloc := 0.0
+	for _, a := range layer.Allocations {
+		if a.Allocation < 0 {
+			return fmt.Errorf("Layer %s allocation %v for campaign %s is negative", layer.ID, a.Allocation, a.CampaignID)
+		}
+		summedAlloc += a.Allocation
+	}
+
+	if summedAlloc > 100+allocationEpsilon {
+		return fmt.Errorf("Layer %s allocations sum to %v, more than 100", layer.ID, summedAlloc)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package bucketing
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createLayerTestCampaign(id string) *Campaign {
+	return &Campaign{
+		ID: id,
+		VariationGroups: []*VariationGroup{{
+			ID: id + "_vgid",
+			Targeting: TargetingWrapper{
+				TargetingGroups: []*TargetingGroup{{
+					Targetings: []*Targeting{{
+						Operator: EQUALS,
+						Key:      "fs_all_users",
+						Value:    true,
+					}},
+				}},
+			},
+			Variations: []*Variation{{ID: id + "_v1", Allocation: 100}},
+		}},
+	}
+}
+
+func TestLayerAssignments(t *testing.T) {
+	layers := []*Layer{{
+		ID: "layer",
+		Allocations: []*LayerAllocation{
+			{CampaignID: "c1", Allocation: 30},
+			{CampaignID: "c2", Allocation: 30},
+		},
+	}}
+
+	counts := map[string]int{}
+	countTotal := 100000
+	for i := 0; i < countTotal; i++ {
+		vID := strconv.Itoa(i)
+		assignments := layerAssignments(layers, nil, func(c *Campaign) string { return vID })
+		assert.Equal(t, 2, len(assignments))
+
+		nbAssigned := 0
+		for cID, assigned := range assignments {
+			if assigned {
+				counts[cID]++
+				nbAssigned++
+			}
+		}
+		assert.True(t, nbAssigned <= 1)
+	}
+
+	for _, cID := range []string{"c1", "c2"} {
+		ratio := float64(counts[cID]) / float64(countTotal)
+		if math.Abs(ratio-0.3) > 0.01 {
+			t.Errorf("Problem with layer stats for %s: ratio %f, correctRatio : %f", cID, ratio, 0.3)
+		}
+	}
+
+	assert.Nil(t, layerAssignments(nil, nil, func(c *Campaign) string { return "vid" }))
+}
+
+func TestLayerBucketingKey(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	config := &Configuration{
+		Campaigns: []*Campaign{
+			createLayerTestCampaign("c1"),
+			createLayerTestCampaign("c2"),
+		},
+		Layers: []*Layer{{
+			ID: "layer",
+			Allocations: []*LayerAllocation{
+				{CampaignID: "c1", Allocation: 50},
+				{CampaignID: "c2", Allocation: 50},
+			},
+		}},
+	}
+	for _, c := range config.Campaigns {
+		c.AllocationVersion = AllocationV2
+		c.BucketingKey = "company_id"
+	}
+	engine.apiClient = NewAPIClientMock(testEnvID, config, 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	// Users of the same company get the same campaign of the layer
+	for _, company := range []string{"company_1", "company_2", "company_3", "company_4"} {
+		campaignID := ""
+		for i := 0; i < 20; i++ {
+			modifs, err := engine.GetModifications(strconv.Itoa(i), map[string]interface{}{"company_id": company})
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(modifs.Campaigns))
+			if campaignID == "" {
+				campaignID = modifs.Campaigns[0].ID
+			}
+			assert.Equal(t, campaignID, modifs.Campaigns[0].ID)
+		}
+	}
+
+	config.Campaigns[1].BucketingKey = ""
+	errs := ValidateConfiguration(config).(ValidationErrors)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "layers[0]", errs[0].Path)
+}
+
+func TestTrafficAllocation(t *testing.T) {
+	traffic := 20.0
+	campaign := &Campaign{ID: "c1", TrafficAllocation: &traffic}
+
+	count := 0
+	countTotal := 100000
+	for i := 0; i < countTotal; i++ {
+		if inTrafficAllocation(campaign, strconv.Itoa(i)) {
+			count++
+		}
+	}
+
+	ratio := float64(count) / float64(countTotal)
+	if math.Abs(ratio-0.2) > 0.01 {
+		t.Errorf("Problem with traffic stats: ratio %f, correctRatio : %f", ratio, 0.2)
+	}
+
+	assert.True(t, inTrafficAllocation(&Campaign{}, "vid"))
+}
+
+func TestValidateLayer(t *testing.T) {
+	layer := &Layer{
+		ID: "layer",
+		Allocations: []*LayerAllocation{
+			{CampaignID: "c1", Allocation: 60},
+			{CampaignID: "c2", Allocation: 40},
+		},
+	}
+	assert.Nil(t, ValidateLayer(layer))
+
+	layer.Allocations[1].Allocation = 41
+	assert.NotNil(t, ValidateLayer(layer))
+
+	layer.Allocations[1].Allocation = -1
+	assert.NotNil(t, ValidateLayer(layer))
+}
+
+func TestEngineLayers(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{
+		Campaigns: []*Campaign{
+			createLayerTestCampaign("c1"),
+			createLayerTestCampaign("c2"),
+			createLayerTestCampaign("c3"),
+		},
+		Layers: []*Layer{{
+			ID: "layer",
+			Allocations: []*LayerAllocation{
+				{CampaignID: "c1", Allocation: 50},
+				{CampaignID: "c2", Allocation: 50},
+			},
+		}},
+	}, 200)
+
+	for i := 0; i < 100; i++ {
+		modifs, err := engine.GetModifications(strconv.Itoa(i), map[string]interface{}{})
+		assert.Nil(t, err)
+
+		// Visitors get exactly one campaign of the layer, and the campaign outside of the layer
+		assert.Equal(t, 2, len(modifs.Campaigns))
+		assert.NotEqual(t, "c3", modifs.Campaigns[0].ID)
+		assert.Equal(t, "c3", modifs.Campaigns[1].ID)
+	}
+}
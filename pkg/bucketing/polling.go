@@ -0,0 +1,72 @@
+package bucketing
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const defaultPollingMaxBackoff = 10 * time.Minute
+const minPollingInterval = 1 * time.Second
+
+// PollingBackoff sets the maximum delay between two polls after consecutive configuration load failures
+func PollingBackoff(max time.Duration) func(r *Engine) {
+	return func(r *Engine) {
+		r.pollingMaxBackoff = max
+	}
+}
+
+// ConsecutiveFailures returns the number of configuration loads that failed since the last successful one
+func (b *Engine) ConsecutiveFailures() int {
+	return int(atomic.LoadInt32(&b.consecutiveFailures))
+}
+
+// LastSuccessfulLoad returns the time of the last successful configuration load, the zero time if none succeeded
+func (b *Engine) LastSuccessfulLoad() time.Time {
+	nano := atomic.LoadInt64(&b.lastSuccessfulLoad)
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nano)
+}
+
+// recordLoad updates the load failure counter and the last successful load time
+func (b *Engine) recordLoad(err error) {
+	if err != nil {
+		atomic.AddInt32(&b.consecutiveFailures, 1)
+		return
+	}
+	atomic.StoreInt32(&b.consecutiveFailures, 0)
+	atomic.StoreInt64(&b.lastSuccessfulLoad, b.now().UnixNano())
+}
+
+// initialPollingDelay returns a random delay before the first poll so that instances started together do not poll in lockstep
+func initialPollingDelay(interval time.Duration, random *rand.Rand) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	return time.Duration(random.Int63n(int64(interval)))
+}
+
+// nextPollingDelay returns the delay before the next poll. The interval is doubled for each consecutive failure up to max,
+// and jitter (between 0 and 1) spreads the delay between half and all of it
+func nextPollingDelay(interval time.Duration, max time.Duration, failures int, jitter float64) time.Duration {
+	if failures <= 0 {
+		return interval
+	}
+
+	if max < interval {
+		max = interval
+	}
+
+	delay := interval
+	for i := 0; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	return half + time.Duration(float64(delay-half)*jitter)
+}
@@ -0,0 +1,92 @@
+package bucketing
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingAPIClient struct{}
+
+func (c failingAPIClient) GetConfiguration() (*Configuration, error) {
+	return nil, errors.New("CDN unavailable")
+}
+
+func TestNextPollingDelay(t *testing.T) {
+	assert.Equal(t, 1*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 0, 0.5))
+
+	assert.Equal(t, 1*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 1, 0))
+	assert.Equal(t, 2*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 1, 1))
+	assert.Equal(t, 3*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 2, 0.5))
+
+	assert.Equal(t, 5*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 100, 0))
+	assert.Equal(t, 10*time.Minute, nextPollingDelay(1*time.Minute, 10*time.Minute, 100, 1))
+
+	// The maximum backoff is never lower than the polling interval
+	assert.Equal(t, 10*time.Minute, nextPollingDelay(20*time.Minute, 10*time.Minute, 3, 0))
+}
+
+func TestInitialPollingDelay(t *testing.T) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	assert.Equal(t, time.Duration(0), initialPollingDelay(-1, random))
+
+	for i := 0; i < 100; i++ {
+		delay := initialPollingDelay(1*time.Second, random)
+		assert.True(t, delay >= 0 && delay < 1*time.Second)
+	}
+}
+
+func TestLoadFailures(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	engine.apiClient = failingAPIClient{}
+	engine.Load()
+	engine.Load()
+	assert.True(t, engine.ConsecutiveFailures() >= 2)
+	assert.True(t, engine.LastSuccessfulLoad().IsZero())
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{}, 200)
+	engine.Load()
+	assert.Equal(t, 0, engine.ConsecutiveFailures())
+	assert.True(t, now.Equal(engine.LastSuccessfulLoad()))
+
+	engine.apiClient = failingAPIClient{}
+	engine.Load()
+	assert.Equal(t, 1, engine.ConsecutiveFailures())
+	assert.True(t, now.Equal(engine.LastSuccessfulLoad()))
+}
+
+func TestStreamedLoadRecorded(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	engine.applyStreamEvent(streamMessageEvent, []byte(`{"campaigns":[]}`))
+	assert.Equal(t, 0, engine.ConsecutiveFailures())
+	assert.True(t, now.Equal(engine.LastSuccessfulLoad()))
+
+	engine.applyStreamEvent(streamMessageEvent, []byte(`{"campaigns":`))
+	assert.Equal(t, 1, engine.ConsecutiveFailures())
+}
+
+func TestMinPollingInterval(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(0))
+	assert.Equal(t, minPollingInterval, engine.pollingInterval)
+	eg.TerminateAndWait()
+
+	eg = utils.NewExecGroup(context.Background())
+	engine, _ = NewEngine(testEnvID, eg, PollingInterval(-1))
+	assert.Equal(t, time.Duration(-1), engine.pollingInterval)
+}
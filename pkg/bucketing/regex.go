@@ -0,0 +1,46 @@
+package bucketing
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// maxRegexPatternLength is the maximum length of a targeting regex pattern
+const maxRegexPatternLength = 1024
+
+// maxRegexInputLength is the maximum length of a context value matched against a regex
+const maxRegexInputLength = 8192
+
+// newRegex compiles a targeting pattern, rejecting patterns that are too long
+func newRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxRegexPatternLength {
+		return nil, fmt.Errorf("Regex pattern is too long (%d characters, max %d)", len(pattern), maxRegexPatternLength)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid regex pattern %q : %v", pattern, err)
+	}
+	return re, nil
+}
+
+// regexMatch returns true if the context value matches the targeting pattern.
+// Patterns use the RE2 syntax which guarantees a matching time linear in the size of the input.
+// The pattern is compiled on each call, compiled targetings keep their compiled pattern instead
+func regexMatch(pattern string, value string) (bool, error) {
+	re, err := newRegex(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return compiledRegexMatch(re, value)
+}
+
+// compiledRegexMatch returns true if the context value matches the compiled targeting pattern
+func compiledRegexMatch(re *regexp.Regexp, value string) (bool, error) {
+	if len(value) > maxRegexInputLength {
+		return false, fmt.Errorf("Context value is too long to be matched against a regex (%d characters, max %d)", len(value), maxRegexInputLength)
+	}
+
+	return re.MatchString(value), nil
+}
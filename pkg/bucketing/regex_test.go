@@ -0,0 +1,33 @@
+package bucketing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexMatch(t *testing.T) {
+	match, err := regexMatch("^ab+c$", "abbbc")
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	match, err = regexMatch("^ab+c$", "ac")
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	_, err = regexMatch("^ab(c", "abc")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Invalid regex pattern")
+
+	_, err = regexMatch(strings.Repeat("a", maxRegexPatternLength+1), "abc")
+	assert.NotNil(t, err)
+
+	_, err = regexMatch("a", strings.Repeat("a", maxRegexInputLength+1))
+	assert.NotNil(t, err)
+
+	// Nested quantifiers do not backtrack with RE2
+	match, err = regexMatch("^(a+)+$", strings.Repeat("a", 5000)+"b")
+	assert.Nil(t, err)
+	assert.False(t, match)
+}
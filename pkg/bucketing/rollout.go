@@ -0,0 +1,98 @@
+package bucketing
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CampaignTypeRollout is the type of the campaigns progressively rolled out following their rollout steps
+const CampaignTypeRollout = "rollout"
+
+// rolloutSalt is the hash scope prefix of the campaign rollout enrollment
+const rolloutSalt = "rollout"
+
+// RolloutStep represents the percentage of visitors enrolled in a rollout campaign from a given date
+type RolloutStep struct {
+	Date       time.Time `json:"date"`
+	Percentage float64   `json:"percentage"`
+}
+
+// RolloutPercentage returns the percentage of visitors enrolled in the campaign at the given time,
+// which is the percentage of the last step started. Campaigns that are not rollouts enroll all visitors
+func RolloutPercentage(campaign *Campaign, now time.Time) float64 {
+	if campaign.Type != CampaignTypeRollout {
+		return 100
+	}
+
+	percentage := 0.0
+	for _, step := range campaign.RolloutSteps {
+		if now.Before(step.Date) {
+			break
+		}
+		percentage = step.Percentage
+	}
+	return percentage
+}
+
+// inRollout returns true if the visitor is enrolled in the rollout campaign at the given time.
+// The visitor bucket does not depend on the time so enrolled visitors stay enrolled as the percentage grows
+func inRollout(campaign *Campaign, bucketingID string, now time.Time) bool {
+	if campaign.Type != CampaignTypeRollout {
+		return true
+	}
+
+	z := float64(scopedHash(rolloutSalt+campaign.ID, bucketingID) % layerBucketSpace)
+	return z < math.Round(RolloutPercentage(campaign, now)*layerBucketSpace/100)
+}
+
+// ValidateRollout checks that the rollout steps are sorted by date and that their percentages are between 0 and 100 and do not decrease
+func ValidateRollout(campaign *Campaign) error {
+	if campaign.Type != CampaignTypeRollout {
+		if len(campaign.RolloutSteps) > 0 {
+			return fmt.Errorf("Campaign %s has rollout steps but is not of type %s", campaign.ID, CampaignTypeRollout)
+		}
+		return nil
+	}
+
+	if len(campaign.RolloutSteps) == 0 {
+		return fmt.Errorf("Rollout campaign %s has no rollout step", campaign.ID)
+	}
+
+	var previous *RolloutStep
+	for _, step := range campaign.RolloutSteps {
+		if step == nil {
+			return fmt.Errorf("Rollout campaign %s has an empty rollout step", campaign.ID)
+		}
+		if step.Percentage < 0 || step.Percentage > 100 {
+			return fmt.Errorf("Rollout campaign %s percentage %v is not between 0 and 100", campaign.ID, step.Percentage)
+		}
+		if previous != nil && !step.Date.After(previous.Date) {
+			return fmt.Errorf("Rollout campaign %s steps are not sorted by date", campaign.ID)
+		}
+		if previous != nil && step.Percentage < previous.Percentage {
+			return fmt.Errorf("Rollout campaign %s percentage decreases from %v to %v", campaign.ID, previous.Percentage, step.Percentage)
+		}
+		previous = step
+	}
+	return nil
+}
+
+// rolloutChanged returns true if the rollout steps differ
+func rolloutChanged(oldSteps []*RolloutStep, newSteps []*RolloutStep) bool {
+	if len(oldSteps) != len(newSteps) {
+		return true
+	}
+	for i, step := range newSteps {
+		if step == nil || oldSteps[i] == nil {
+			if step != oldSteps[i] {
+				return true
+			}
+			continue
+		}
+		if !step.Date.Equal(oldSteps[i].Date) || step.Percentage != oldSteps[i].Percentage {
+			return true
+		}
+	}
+	return false
+}
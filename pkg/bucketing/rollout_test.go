@@ -0,0 +1,126 @@
+package bucketing
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var rolloutStart = time.Date(2020, 6, 1, 8, 0, 0, 0, time.UTC)
+
+func createRolloutCampaign() *Campaign {
+	return &Campaign{
+		ID:   "rollout_cid",
+		Type: CampaignTypeRollout,
+		RolloutSteps: []*RolloutStep{
+			{Date: rolloutStart, Percentage: 5},
+			{Date: rolloutStart.Add(4 * time.Hour), Percentage: 25},
+			{Date: rolloutStart.Add(8 * time.Hour), Percentage: 100},
+		},
+		VariationGroups: []*VariationGroup{{
+			ID: "rollout_vgid",
+			Targeting: TargetingWrapper{
+				TargetingGroups: []*TargetingGroup{{
+					Targetings: []*Targeting{{Operator: EQUALS, Key: "fs_all_users", Value: true}},
+				}},
+			},
+			Variations: []*Variation{{ID: "1", Allocation: 100}},
+		}},
+	}
+}
+
+func TestRolloutPercentage(t *testing.T) {
+	campaign := createRolloutCampaign()
+
+	assert.Equal(t, 0.0, RolloutPercentage(campaign, rolloutStart.Add(-time.Second)))
+	assert.Equal(t, 5.0, RolloutPercentage(campaign, rolloutStart))
+	assert.Equal(t, 5.0, RolloutPercentage(campaign, rolloutStart.Add(4*time.Hour-time.Second)))
+	assert.Equal(t, 25.0, RolloutPercentage(campaign, rolloutStart.Add(4*time.Hour)))
+	assert.Equal(t, 100.0, RolloutPercentage(campaign, rolloutStart.Add(48*time.Hour)))
+
+	assert.Equal(t, 100.0, RolloutPercentage(&Campaign{}, rolloutStart))
+}
+
+func TestRolloutEnrollment(t *testing.T) {
+	campaign := createRolloutCampaign()
+	countTotal := 20000
+
+	enrolled := map[string]bool{}
+	for i := 0; i < countTotal; i++ {
+		vID := strconv.Itoa(i)
+		if inRollout(campaign, vID, rolloutStart) {
+			enrolled[vID] = true
+		}
+	}
+
+	ratio := float64(len(enrolled)) / float64(countTotal)
+	if math.Abs(ratio-0.05) > 0.01 {
+		t.Errorf("Problem with stats: ratio %f, correctRatio : %f", ratio, 0.05)
+	}
+
+	// Visitors enrolled at 5% stay enrolled at 25%
+	count := 0
+	for i := 0; i < countTotal; i++ {
+		vID := strconv.Itoa(i)
+		if inRollout(campaign, vID, rolloutStart.Add(4*time.Hour)) {
+			count++
+		} else if enrolled[vID] {
+			t.Errorf("Visitor %s left the rollout as the percentage grew", vID)
+		}
+	}
+
+	ratio = float64(count) / float64(countTotal)
+	if math.Abs(ratio-0.25) > 0.01 {
+		t.Errorf("Problem with stats: ratio %f, correctRatio : %f", ratio, 0.25)
+	}
+
+	assert.True(t, inRollout(&Campaign{}, "vid", rolloutStart))
+}
+
+func TestValidateRollout(t *testing.T) {
+	campaign := createRolloutCampaign()
+	assert.Nil(t, ValidateRollout(campaign))
+	assert.Nil(t, ValidateRollout(&Campaign{}))
+
+	assert.NotNil(t, ValidateRollout(&Campaign{Type: CampaignTypeRollout}))
+	assert.NotNil(t, ValidateRollout(&Campaign{RolloutSteps: campaign.RolloutSteps}))
+
+	campaign.RolloutSteps[1].Percentage = 1
+	assert.NotNil(t, ValidateRollout(campaign))
+
+	campaign = createRolloutCampaign()
+	campaign.RolloutSteps[2].Date = rolloutStart
+	assert.NotNil(t, ValidateRollout(campaign))
+
+	campaign = createRolloutCampaign()
+	campaign.RolloutSteps[2].Percentage = 101
+	assert.NotNil(t, ValidateRollout(campaign))
+}
+
+func TestEngineRollout(t *testing.T) {
+	now := rolloutStart.Add(-time.Hour)
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), Clock(func() time.Time { return now }))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{Campaigns: []*Campaign{createRolloutCampaign()}}, 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	modifs, err := engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(modifs.Campaigns))
+
+	explanation, _ := engine.Explain(testVID, map[string]interface{}{})
+	assert.Equal(t, ReasonRollout, explanation.Campaigns[0].Reason)
+
+	now = rolloutStart.Add(8 * time.Hour)
+	modifs, err = engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(modifs.Campaigns))
+	assert.Equal(t, CampaignTypeRollout, modifs.Campaigns[0].Type)
+}
@@ -0,0 +1,40 @@
+package bucketing
+
+import (
+	"time"
+)
+
+// Clock sets the function returning the current time, used for campaign schedules and date targetings
+func Clock(now func() time.Time) func(r *Engine) {
+	return func(r *Engine) {
+		r.now = now
+	}
+}
+
+// inSchedule returns true if the time is within the optional start date (included) and end date (excluded)
+func inSchedule(startDate *time.Time, endDate *time.Time, now time.Time) bool {
+	if startDate != nil && now.Before(*startDate) {
+		return false
+	}
+	if endDate != nil && !now.Before(*endDate) {
+		return false
+	}
+	return true
+}
+
+// validateSchedule returns false if the end date is not after the start date
+func validateSchedule(startDate *time.Time, endDate *time.Time) bool {
+	return startDate == nil || endDate == nil || endDate.After(*startDate)
+}
+
+// scheduleChanged returns true if the start or end date differ
+func scheduleChanged(oldStartDate *time.Time, oldEndDate *time.Time, newStartDate *time.Time, newEndDate *time.Time) bool {
+	return !sameTime(oldStartDate, newStartDate) || !sameTime(oldEndDate, newEndDate)
+}
+
+func sameTime(t1 *time.Time, t2 *time.Time) bool {
+	if t1 == nil || t2 == nil {
+		return t1 == t2
+	}
+	return t1.Equal(*t2)
+}
@@ -0,0 +1,110 @@
+package bucketing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInSchedule(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, inSchedule(nil, nil, start))
+	assert.True(t, inSchedule(&start, &end, start))
+	assert.True(t, inSchedule(&start, &end, end.Add(-time.Second)))
+	assert.False(t, inSchedule(&start, &end, end))
+	assert.False(t, inSchedule(&start, nil, start.Add(-time.Second)))
+	assert.True(t, inSchedule(nil, &end, start))
+
+	assert.True(t, validateSchedule(&start, &end))
+	assert.False(t, validateSchedule(&end, &start))
+	assert.False(t, validateSchedule(&start, &start))
+}
+
+func TestCampaignScheduleJSON(t *testing.T) {
+	campaign := &Campaign{}
+	err := json.Unmarshal([]byte(`{"id":"cid","startDate":"2020-06-01T00:00:00Z","endDate":"2020-06-02T00:00:00+02:00"}`), campaign)
+	assert.Nil(t, err)
+	assert.True(t, campaign.StartDate.Equal(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, campaign.EndDate.Equal(time.Date(2020, 6, 1, 22, 0, 0, 0, time.UTC)))
+}
+
+func TestEngineSchedule(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(-time.Hour)
+
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), Clock(func() time.Time { return now }))
+
+	newVg := func(id string) *VariationGroup {
+		return &VariationGroup{
+			ID: id,
+			Targeting: TargetingWrapper{
+				TargetingGroups: []*TargetingGroup{{
+					Targetings: []*Targeting{{Operator: EQUALS, Key: "fs_all_users", Value: true}},
+				}},
+			},
+			Variations: []*Variation{{ID: "1", Allocation: 100}},
+		}
+	}
+	scheduledVg := newVg("scheduled_vgid")
+	scheduledVg.EndDate = &end
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{
+		Campaigns: []*Campaign{{
+			ID:              "test_cid",
+			StartDate:       &start,
+			VariationGroups: []*VariationGroup{scheduledVg, newVg("default_vgid")},
+		}},
+	}, 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	// Before the campaign start date
+	modifs, err := engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(modifs.Campaigns))
+
+	explanation, _ := engine.Explain(testVID, map[string]interface{}{})
+	assert.Equal(t, ReasonSchedule, explanation.Campaigns[0].Reason)
+
+	// Within the campaign and the variation group schedules
+	now = start
+	modifs, err = engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(modifs.Campaigns))
+	assert.Equal(t, "scheduled_vgid", modifs.Campaigns[0].VariationGroupID)
+
+	// After the variation group end date, the next variation group is used
+	now = end
+	modifs, err = engine.GetModifications(testVID, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(modifs.Campaigns))
+	assert.Equal(t, "default_vgid", modifs.Campaigns[0].VariationGroupID)
+
+	explanation, _ = engine.Explain(testVID, map[string]interface{}{})
+	assert.True(t, explanation.Campaigns[0].VariationGroups[0].OutOfSchedule)
+	assert.Equal(t, "default_vgid", explanation.Campaigns[0].MatchedVariationGroupID)
+}
+
+func TestScheduleValidationAndDiff(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	errs := ValidateConfiguration(&Configuration{Campaigns: []*Campaign{{ID: "cid", StartDate: &end, EndDate: &start}}}).(ValidationErrors)
+	assert.Equal(t, 1, len(errs))
+
+	oldConfig := &Configuration{Campaigns: []*Campaign{{ID: "cid", VariationGroups: []*VariationGroup{{ID: "vgid"}}}}}
+	newConfig := &Configuration{Campaigns: []*Campaign{{ID: "cid", EndDate: &end, VariationGroups: []*VariationGroup{{ID: "vgid", StartDate: &start}}}}}
+
+	diff := DiffConfigurations(oldConfig, newConfig)
+	assert.Equal(t, 1, len(diff.ChangedCampaigns))
+	assert.True(t, diff.ChangedCampaigns[0].ScheduleChanged)
+	assert.Equal(t, []string{"vgid"}, diff.ChangedCampaigns[0].ScheduleChangedGroups)
+}
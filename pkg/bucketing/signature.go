@@ -0,0 +1,81 @@
+package bucketing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// SignatureHeader is the response header holding the base64 encoded signature of the bucketing configuration
+const SignatureHeader = "X-Signature"
+
+var errMissingSignature = errors.New("Configuration is not signed")
+var errInvalidSignature = errors.New("Configuration signature is invalid")
+
+// SignedConfigAPIInterface is implemented by configuration sources that return the raw configuration payload and its detached signature
+type SignedConfigAPIInterface interface {
+	GetSignedConfiguration() (payload []byte, signature []byte, err error)
+}
+
+// SignatureKey enables the verification of the configuration Ed25519 signature with the given public key.
+// Unsigned or badly signed configurations are refused
+func SignatureKey(publicKey ed25519.PublicKey) func(r *Engine) {
+	return func(r *Engine) {
+		r.signatureKey = publicKey
+	}
+}
+
+// verifySignature checks the detached signature of a configuration payload
+func verifySignature(publicKey ed25519.PublicKey, payload []byte, signature []byte) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("Invalid signature public key size %d", len(publicKey))
+	}
+	if len(signature) == 0 {
+		return errMissingSignature
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// decodeSignature decodes a base64 encoded signature
+func decodeSignature(signature string) ([]byte, error) {
+	if signature == "" {
+		return nil, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid configuration signature encoding : %v", err)
+	}
+	return decoded, nil
+}
+
+// getSignedConfiguration gets the configuration payload and its signature from the API client and verifies it
+func (b *Engine) getSignedConfiguration() (*Configuration, []byte, []byte, error) {
+	source, ok := b.apiClient.(SignedConfigAPIInterface)
+	if !ok {
+		return nil, nil, nil, errors.New("Configuration source does not provide signatures")
+	}
+
+	payload, signature, err := source.GetSignedConfiguration()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = verifySignature(b.signatureKey, payload, signature)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	config := &Configuration{}
+	err = json.Unmarshal(payload, config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return config, payload, signature, nil
+}
@@ -0,0 +1,117 @@
+package bucketing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+type signedAPIClientMock struct {
+	payload   []byte
+	signature []byte
+}
+
+func (c signedAPIClientMock) GetConfiguration() (*Configuration, error) {
+	return &Configuration{}, nil
+}
+
+func (c signedAPIClientMock) GetSignedConfiguration() ([]byte, []byte, error) {
+	return c.payload, c.signature, nil
+}
+
+func createTestSigningKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestVerifySignature(t *testing.T) {
+	privateKey := createTestSigningKey(1)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	payload := []byte(`{"panic":false,"campaigns":[]}`)
+	signature := ed25519.Sign(privateKey, payload)
+
+	assert.Nil(t, verifySignature(publicKey, payload, signature))
+	assert.Equal(t, errInvalidSignature, verifySignature(publicKey, []byte(`{"panic":true,"campaigns":[]}`), signature))
+	assert.Equal(t, errInvalidSignature, verifySignature(createTestSigningKey(2).Public().(ed25519.PublicKey), payload, signature))
+	assert.Equal(t, errMissingSignature, verifySignature(publicKey, payload, nil))
+	assert.NotNil(t, verifySignature(publicKey[:10], payload, signature))
+}
+
+func TestAPIClientSignedConfiguration(t *testing.T) {
+	privateKey := createTestSigningKey(1)
+	payload := []byte(`{"panic":false,"campaigns":[{"id":"test_cid"}]}`)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(SignatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload)))
+		w.Write(payload)
+	}))
+	defer ts.Close()
+
+	client := NewAPIClient(testEnvID, APIUrl(ts.URL))
+	response, signature, err := client.GetSignedConfiguration()
+	assert.Nil(t, err)
+	assert.Equal(t, payload, response)
+	assert.Nil(t, verifySignature(privateKey.Public().(ed25519.PublicKey), response, signature))
+}
+
+func TestLoadSignedConfiguration(t *testing.T) {
+	privateKey := createTestSigningKey(1)
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), SignatureKey(privateKey.Public().(ed25519.PublicKey)))
+
+	payload := []byte(`{"panic":false,"campaigns":[{"id":"test_cid"}]}`)
+	engine.apiClient = signedAPIClientMock{payload: payload, signature: ed25519.Sign(privateKey, payload)}
+	err := engine.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "test_cid", engine.getConfig().Campaigns[0].ID)
+
+	// Tampered and unsigned configurations are refused and the previous configuration is kept
+	engine.apiClient = signedAPIClientMock{payload: []byte(`{"panic":true,"campaigns":[]}`), signature: ed25519.Sign(privateKey, payload)}
+	assert.Equal(t, errInvalidSignature, engine.Load())
+
+	engine.apiClient = signedAPIClientMock{payload: payload}
+	assert.Equal(t, errMissingSignature, engine.Load())
+
+	engine.apiClient = NewAPIClientMock(testEnvID, &Configuration{Panic: true}, 200)
+	assert.NotNil(t, engine.Load())
+
+	assert.Equal(t, false, engine.getConfig().Panic)
+	assert.Equal(t, "test_cid", engine.getConfig().Campaigns[0].ID)
+}
+
+func TestSignedConfigurationCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	privateKey := createTestSigningKey(1)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	payload := []byte(`{"panic":true,"campaigns":[]}`)
+
+	path := filepath.Join(dir, "signed.json")
+	err = writeConfigurationCache(path, payload, ed25519.Sign(privateKey, payload))
+	assert.Nil(t, err)
+
+	cached, err := readConfigurationCache(path, publicKey)
+	assert.Nil(t, err)
+	assert.Equal(t, true, cached.Panic)
+
+	_, err = readConfigurationCache(path, createTestSigningKey(2).Public().(ed25519.PublicKey))
+	assert.Equal(t, errInvalidSignature, err)
+
+	path = filepath.Join(dir, "unsigned.json")
+	err = writeConfigurationCache(path, payload, nil)
+	assert.Nil(t, err)
+
+	_, err = readConfigurationCache(path, publicKey)
+	assert.Equal(t, errMissingSignature, err)
+}
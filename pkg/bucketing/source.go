@@ -0,0 +1,219 @@
+package bucketing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultWatchInterval = 1 * time.Second
+
+// ConfigSourceWatcher is implemented by configuration sources that can notify the engine when the configuration changes
+type ConfigSourceWatcher interface {
+	Watch(ctx context.Context, onChange func())
+}
+
+// ConfigSource sets the source the engine loads its configuration from, instead of the bucketing API.
+// API options are ignored when a source is set
+func ConfigSource(source ConfigAPIInterface) func(r *Engine) {
+	return func(r *Engine) {
+		r.apiClient = source
+	}
+}
+
+// FileSource represents a configuration source reading a local JSON file
+type FileSource struct {
+	path          string
+	watchInterval time.Duration
+	readState     string
+	readStateMux  sync.Mutex
+}
+
+// WatchInterval sets the interval between two checks of the file for changes
+func WatchInterval(interval time.Duration) func(r *FileSource) {
+	return func(r *FileSource) {
+		r.watchInterval = interval
+	}
+}
+
+// NewFileSource creates a configuration source reading the given file. The signature, if any, is read from the file with the .sig suffix
+func NewFileSource(path string, params ...func(*FileSource)) *FileSource {
+	source := &FileSource{
+		path:          path,
+		watchInterval: defaultWatchInterval,
+	}
+
+	for _, param := range params {
+		param(source)
+	}
+
+	return source
+}
+
+// NewDirectorySource creates a configuration source reading the <envID>.json file of a directory of per-env configurations
+func NewDirectorySource(dir string, envID string, params ...func(*FileSource)) *FileSource {
+	return NewFileSource(filepath.Join(dir, fmt.Sprintf("%s.json", envID)), params...)
+}
+
+// GetConfiguration reads the configuration file
+func (r *FileSource) GetConfiguration() (*Configuration, error) {
+	content, err := r.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Configuration{}
+	err = json.Unmarshal(content, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetSignedConfiguration reads the configuration file and its base64 encoded signature file
+func (r *FileSource) GetSignedConfiguration() ([]byte, []byte, error) {
+	content, err := r.readFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := ioutil.ReadFile(r.path + ".sig")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	decoded, err := decodeSignature(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, decoded, nil
+}
+
+// Watch checks the modification time and size of the file at each watch interval and calls onChange when they change
+func (r *FileSource) Watch(ctx context.Context, onChange func()) {
+	// Changes made since the file was last read are notified at the first check
+	r.readStateMux.Lock()
+	last := r.readState
+	r.readStateMux.Unlock()
+	if last == "" {
+		last = r.fileState()
+	}
+
+	ticker := time.NewTicker(r.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			state := r.fileState()
+			if state != last {
+				last = state
+				logger.Info(fmt.Sprintf("Configuration file %s changed", r.path))
+				onChange()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readFile reads the file and keeps the state of the version read
+func (r *FileSource) readFile() ([]byte, error) {
+	state := r.fileState()
+	content, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.readStateMux.Lock()
+	r.readState = state
+	r.readStateMux.Unlock()
+
+	return content, nil
+}
+
+// fileState returns a string identifying the current version of the file, empty if it does not exist
+func (r *FileSource) fileState() string {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d_%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// ChainSource represents a configuration source trying each of its sources in order
+type ChainSource struct {
+	sources []ConfigAPIInterface
+}
+
+// NewChainSource creates a configuration source returning the configuration of the first source that succeeds
+func NewChainSource(sources ...ConfigAPIInterface) *ChainSource {
+	return &ChainSource{
+		sources: sources,
+	}
+}
+
+// GetConfiguration returns the configuration of the first source that succeeds
+func (r *ChainSource) GetConfiguration() (*Configuration, error) {
+	errs := []string{}
+	for _, source := range r.sources {
+		config, err := source.GetConfiguration()
+		if err == nil {
+			return config, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, chainError(errs)
+}
+
+// GetSignedConfiguration returns the signed configuration of the first source providing signatures that succeeds
+func (r *ChainSource) GetSignedConfiguration() ([]byte, []byte, error) {
+	errs := []string{}
+	for _, source := range r.sources {
+		signedSource, ok := source.(SignedConfigAPIInterface)
+		if !ok {
+			continue
+		}
+		payload, signature, err := signedSource.GetSignedConfiguration()
+		if err == nil {
+			return payload, signature, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, nil, chainError(errs)
+}
+
+// Watch watches every source that can be watched and calls onChange when one of them changes
+func (r *ChainSource) Watch(ctx context.Context, onChange func()) {
+	done := make(chan bool)
+	count := 0
+	for _, source := range r.sources {
+		if watcher, ok := source.(ConfigSourceWatcher); ok {
+			count++
+			go func() {
+				watcher.Watch(ctx, onChange)
+				done <- true
+			}()
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		<-done
+	}
+}
+
+func chainError(errs []string) error {
+	if len(errs) == 0 {
+		return errors.New("No configuration source available")
+	}
+	return fmt.Errorf("All configuration sources failed : %s", strings.Join(errs, ", "))
+}
@@ -0,0 +1,122 @@
+package bucketing
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_source")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	source := NewDirectorySource(dir, testEnvID)
+	_, err = source.GetConfiguration()
+	assert.True(t, os.IsNotExist(err))
+
+	payload := []byte(`{"panic":true,"campaigns":[{"id":"test_cid"}]}`)
+	err = ioutil.WriteFile(filepath.Join(dir, testEnvID+".json"), payload, 0644)
+	assert.Nil(t, err)
+
+	config, err := source.GetConfiguration()
+	assert.Nil(t, err)
+	assert.Equal(t, true, config.Panic)
+	assert.Equal(t, "test_cid", config.Campaigns[0].ID)
+
+	_, signature, err := source.GetSignedConfiguration()
+	assert.Nil(t, err)
+	assert.Nil(t, signature)
+
+	privateKey := createTestSigningKey(1)
+	err = ioutil.WriteFile(filepath.Join(dir, testEnvID+".json.sig"), []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload))+"\n"), 0644)
+	assert.Nil(t, err)
+
+	content, signature, err := source.GetSignedConfiguration()
+	assert.Nil(t, err)
+	assert.Nil(t, verifySignature(privateKey.Public().(ed25519.PublicKey), content, signature))
+}
+
+func TestFileSourceWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_source")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bucketing.json")
+	source := NewFileSource(path, WatchInterval(10*time.Millisecond))
+
+	changes := int32(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		source.Watch(ctx, func() {
+			atomic.AddInt32(&changes, 1)
+		})
+		done <- true
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&changes))
+
+	err = ioutil.WriteFile(path, []byte(`{"panic":false}`), 0644)
+	assert.Nil(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&changes))
+
+	cancel()
+	<-done
+}
+
+func TestChainSource(t *testing.T) {
+	source := NewChainSource(failingAPIClient{}, NewAPIClientMock(testEnvID, &Configuration{Panic: true}, 200))
+
+	config, err := source.GetConfiguration()
+	assert.Nil(t, err)
+	assert.Equal(t, true, config.Panic)
+
+	_, err = NewChainSource(failingAPIClient{}, failingAPIClient{}).GetConfiguration()
+	assert.NotNil(t, err)
+
+	_, err = NewChainSource().GetConfiguration()
+	assert.NotNil(t, err)
+
+	payload := []byte(`{"panic":false}`)
+	source = NewChainSource(NewAPIClientMock(testEnvID, &Configuration{}, 200), signedAPIClientMock{payload: payload, signature: []byte("sig")})
+	content, signature, err := source.GetSignedConfiguration()
+	assert.Nil(t, err)
+	assert.Equal(t, payload, content)
+	assert.Equal(t, []byte("sig"), signature)
+}
+
+func TestEngineConfigSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_source")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bucketing.json")
+	err = ioutil.WriteFile(path, []byte(`{"campaigns":[{"id":"file_cid"}]}`), 0644)
+	assert.Nil(t, err)
+
+	eg := utils.NewExecGroup(context.Background())
+	source := NewChainSource(failingAPIClient{}, NewFileSource(path, WatchInterval(10*time.Millisecond)))
+	engine, err := NewEngine(testEnvID, eg, PollingInterval(-1), ConfigSource(source))
+	assert.Nil(t, err)
+	assert.Equal(t, "file_cid", engine.getConfig().Campaigns[0].ID)
+
+	// The engine reloads the configuration when the file changes
+	err = ioutil.WriteFile(path, []byte(`{"campaigns":[{"id":"updated_cid"}, {"id":"new_cid"}]}`), 0644)
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "updated_cid", engine.getConfig().Campaigns[0].ID)
+
+	eg.TerminateAndWait()
+}
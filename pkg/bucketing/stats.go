@@ -0,0 +1,205 @@
+package bucketing
+
+import (
+	"sync/atomic"
+)
+
+// Stats represents a snapshot of the engine evaluation counters, by campaign ID
+type Stats struct {
+	Campaigns map[string]*CampaignStats `json:"campaigns"`
+}
+
+// CampaignStats represents the evaluation counters of a campaign
+type CampaignStats struct {
+	Evaluations     int64                           `json:"evaluations"`
+	Matches         int64                           `json:"matches"`
+	Assignments     int64                           `json:"assignments"`
+	Holdouts        int64                           `json:"holdouts"`
+	VariationGroups map[string]*VariationGroupStats `json:"variationGroups"`
+}
+
+// VariationGroupStats represents the evaluation counters of a variation group
+type VariationGroupStats struct {
+	Evaluations int64            `json:"evaluations"`
+	Matches     int64            `json:"matches"`
+	Assignments int64            `json:"assignments"`
+	Variations  map[string]int64 `json:"variations"`
+}
+
+// evaluationCounters holds counters incremented atomically during evaluations
+type evaluationCounters struct {
+	evaluations int64
+	matches     int64
+	assignments int64
+	holdouts    int64
+}
+
+// statsCounters holds the counters of the campaigns, variation groups and variations of a configuration.
+// The maps are never written after creation so that counters can be incremented without lock
+type statsCounters struct {
+	campaigns       map[*Campaign]*evaluationCounters
+	variationGroups map[*VariationGroup]*evaluationCounters
+	variations      map[*Variation]*evaluationCounters
+	byID            map[string]*evaluationCounters
+}
+
+// newStatsCounters creates the counters of a configuration, keeping the counters of the IDs that were in the previous configuration
+func newStatsCounters(config *Configuration, previous *statsCounters) *statsCounters {
+	counters := &statsCounters{
+		campaigns:       map[*Campaign]*evaluationCounters{},
+		variationGroups: map[*VariationGroup]*evaluationCounters{},
+		variations:      map[*Variation]*evaluationCounters{},
+		byID:            map[string]*evaluationCounters{},
+	}
+	if config == nil {
+		return counters
+	}
+
+	get := func(id string) *evaluationCounters {
+		if previous != nil {
+			if c, ok := previous.byID[id]; ok {
+				counters.byID[id] = c
+				return c
+			}
+		}
+		if c, ok := counters.byID[id]; ok {
+			return c
+		}
+		c := &evaluationCounters{}
+		counters.byID[id] = c
+		return c
+	}
+
+	for _, c := range config.Campaigns {
+		if c == nil {
+			continue
+		}
+		counters.campaigns[c] = get(c.ID)
+		for _, vg := range c.VariationGroups {
+			if vg == nil {
+				continue
+			}
+			counters.variationGroups[vg] = get(c.ID + "/" + vg.ID)
+			for _, v := range vg.Variations {
+				if v != nil {
+					counters.variations[v] = get(c.ID + "/" + vg.ID + "/" + v.ID)
+				}
+			}
+		}
+	}
+	return counters
+}
+
+// campaign returns the counters of a campaign, nil if it is not in the configuration
+func (s *statsCounters) campaign(c *Campaign) *evaluationCounters {
+	if s == nil {
+		return nil
+	}
+	return s.campaigns[c]
+}
+
+// variationGroup returns the counters of a variation group, nil if it is not in the configuration
+func (s *statsCounters) variationGroup(vg *VariationGroup) *evaluationCounters {
+	if s == nil {
+		return nil
+	}
+	return s.variationGroups[vg]
+}
+
+// variation returns the counters of a variation, nil if it is not in the configuration
+func (s *statsCounters) variation(v *Variation) *evaluationCounters {
+	if s == nil {
+		return nil
+	}
+	return s.variations[v]
+}
+
+func (c *evaluationCounters) addEvaluation() {
+	if c != nil {
+		atomic.AddInt64(&c.evaluations, 1)
+	}
+}
+
+func (c *evaluationCounters) addMatch() {
+	if c != nil {
+		atomic.AddInt64(&c.matches, 1)
+	}
+}
+
+func (c *evaluationCounters) addAssignment() {
+	if c != nil {
+		atomic.AddInt64(&c.assignments, 1)
+	}
+}
+
+func (c *evaluationCounters) addHoldout() {
+	if c != nil {
+		atomic.AddInt64(&c.holdouts, 1)
+	}
+}
+
+func (c *evaluationCounters) reset() {
+	atomic.StoreInt64(&c.evaluations, 0)
+	atomic.StoreInt64(&c.matches, 0)
+	atomic.StoreInt64(&c.assignments, 0)
+	atomic.StoreInt64(&c.holdouts, 0)
+}
+
+// Stats returns a snapshot of the evaluation, targeting match and assignment counters of the campaigns of the current configuration.
+// Counters of campaigns removed from the configuration are dropped
+func (b *Engine) Stats() *Stats {
+	config, _, counters := b.getCompiledConfig()
+	stats := &Stats{
+		Campaigns: map[string]*CampaignStats{},
+	}
+	if config == nil || counters == nil {
+		return stats
+	}
+
+	for _, c := range config.Campaigns {
+		cc, ok := counters.campaigns[c]
+		if !ok {
+			continue
+		}
+		campaignStats := &CampaignStats{
+			Evaluations:     atomic.LoadInt64(&cc.evaluations),
+			Matches:         atomic.LoadInt64(&cc.matches),
+			Assignments:     atomic.LoadInt64(&cc.assignments),
+			Holdouts:        atomic.LoadInt64(&cc.holdouts),
+			VariationGroups: map[string]*VariationGroupStats{},
+		}
+		stats.Campaigns[c.ID] = campaignStats
+
+		for _, vg := range c.VariationGroups {
+			vgc, ok := counters.variationGroups[vg]
+			if !ok {
+				continue
+			}
+			vgStats := &VariationGroupStats{
+				Evaluations: atomic.LoadInt64(&vgc.evaluations),
+				Matches:     atomic.LoadInt64(&vgc.matches),
+				Assignments: atomic.LoadInt64(&vgc.assignments),
+				Variations:  map[string]int64{},
+			}
+			campaignStats.VariationGroups[vg.ID] = vgStats
+
+			for _, v := range vg.Variations {
+				if vc, ok := counters.variations[v]; ok {
+					vgStats.Variations[v.ID] = atomic.LoadInt64(&vc.assignments)
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// ResetStats resets all the evaluation counters to zero
+func (b *Engine) ResetStats() {
+	_, _, counters := b.getCompiledConfig()
+	if counters == nil {
+		return
+	}
+	for _, c := range counters.byID {
+		c.reset()
+	}
+}
@@ -0,0 +1,113 @@
+package bucketing
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createStatsTestConfig() *Configuration {
+	return &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+			VariationGroups: []*VariationGroup{
+				{
+					ID: "fr_vgid",
+					Targeting: TargetingWrapper{
+						TargetingGroups: []*TargetingGroup{{
+							Targetings: []*Targeting{{Operator: EQUALS, Key: "country", Value: "fr"}},
+						}},
+					},
+					Variations: []*Variation{{ID: "1", Allocation: 50}, {ID: "2", Allocation: 50}},
+				},
+				{
+					ID: "all_vgid",
+					Targeting: TargetingWrapper{
+						TargetingGroups: []*TargetingGroup{{
+							Targetings: []*Targeting{{Operator: EQUALS, Key: "fs_all_users", Value: true}},
+						}},
+					},
+					Variations: []*Variation{{ID: "3", Allocation: 100}},
+				},
+			},
+		}},
+	}
+}
+
+func TestEngineStats(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+	engine.apiClient = NewAPIClientMock(testEnvID, createStatsTestConfig(), 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	countTotal := 10000
+	for i := 0; i < countTotal; i++ {
+		country := "fr"
+		if i%4 == 0 {
+			country = "us"
+		}
+		engine.GetModifications(strconv.Itoa(i), map[string]interface{}{"country": country})
+	}
+
+	stats := engine.Stats().Campaigns["test_cid"]
+	assert.Equal(t, int64(countTotal), stats.Evaluations)
+	assert.Equal(t, int64(countTotal), stats.Matches)
+	assert.Equal(t, int64(countTotal), stats.Assignments)
+
+	frStats := stats.VariationGroups["fr_vgid"]
+	assert.Equal(t, int64(countTotal), frStats.Evaluations)
+	assert.Equal(t, int64(countTotal*3/4), frStats.Matches)
+	assert.Equal(t, frStats.Matches, frStats.Assignments)
+	assert.Equal(t, frStats.Assignments, frStats.Variations["1"]+frStats.Variations["2"])
+
+	ratio := float64(frStats.Variations["1"]) / float64(frStats.Assignments)
+	if math.Abs(ratio-0.5) > 0.02 {
+		t.Errorf("Problem with stats: ratio %f, correctRatio : %f", ratio, 0.5)
+	}
+
+	allStats := stats.VariationGroups["all_vgid"]
+	assert.Equal(t, int64(countTotal/4), allStats.Evaluations)
+	assert.Equal(t, int64(countTotal/4), allStats.Variations["3"])
+
+	// Counters are kept when the configuration is reloaded
+	engine.apiClient = NewAPIClientMock(testEnvID, createStatsTestConfig(), 200)
+	err = engine.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(countTotal), engine.Stats().Campaigns["test_cid"].Evaluations)
+
+	engine.ResetStats()
+	stats = engine.Stats().Campaigns["test_cid"]
+	assert.Equal(t, int64(0), stats.Evaluations)
+	assert.Equal(t, int64(0), stats.VariationGroups["fr_vgid"].Variations["1"])
+}
+
+func TestConcurrentEngineStats(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+	engine.apiClient = NewAPIClientMock(testEnvID, createStatsTestConfig(), 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				engine.GetModifications(strconv.Itoa(w*1000+i), map[string]interface{}{"country": "fr"})
+				engine.Stats()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := engine.Stats().Campaigns["test_cid"]
+	assert.Equal(t, int64(4000), stats.Evaluations)
+	assert.Equal(t, int64(4000), stats.VariationGroups["fr_vgid"].Variations["1"]+stats.VariationGroups["fr_vgid"].Variations["2"])
+}
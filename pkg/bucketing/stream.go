@@ -0,0 +1,192 @@
+package bucketing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const defaultStreamMinBackoff = 1 * time.Second
+const defaultStreamMaxBackoff = 1 * time.Minute
+const maxStreamEventSize = 10 * 1024 * 1024
+
+// Stream event types carrying a configuration. Events without an event field have the default message type
+const (
+	streamConfigurationEvent = "configuration"
+	streamMessageEvent       = "message"
+)
+
+// Streaming enables the Server-Sent Events configuration stream on the given URL.
+// Polling is suspended while the stream is connected and used as a fallback when it is down
+func Streaming(url string) func(r *Engine) {
+	return func(r *Engine) {
+		r.streamURL = url
+	}
+}
+
+// StreamingBackoff sets the minimum and maximum delays between two stream reconnections
+func StreamingBackoff(min time.Duration, max time.Duration) func(r *Engine) {
+	return func(r *Engine) {
+		r.streamMinBackoff = min
+		r.streamMaxBackoff = max
+	}
+}
+
+// IsStreaming returns true if the configuration stream is currently connected
+func (b *Engine) IsStreaming() bool {
+	return atomic.LoadInt32(&b.streamConnected) == 1
+}
+
+// startStream keeps the configuration stream open until the context is done
+func (b *Engine) startStream(ctx context.Context) {
+	backoff := b.streamMinBackoff
+	client := &http.Client{}
+
+	for {
+		connected, err := b.stream(ctx, client)
+		atomic.StoreInt32(&b.streamConnected, 0)
+
+		if ctx.Err() != nil {
+			logger.Info("Bucketing stream stopped")
+			return
+		}
+
+		if connected {
+			backoff = b.streamMinBackoff
+		}
+		logger.Warning(fmt.Sprintf("Bucketing stream disconnected : %v. Reconnecting in %v", err, backoff))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			logger.Info("Bucketing stream stopped")
+			return
+		}
+
+		backoff = nextStreamBackoff(backoff, b.streamMaxBackoff)
+	}
+}
+
+// stream connects to the stream URL and applies the pushed configurations until the connection ends.
+// It returns true if the connection was established
+func (b *Engine) stream(ctx context.Context, client *http.Client) (bool, error) {
+	req, err := http.NewRequest("GET", b.streamURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Error when connecting to Bucketing stream : %s", resp.Status)
+	}
+
+	logger.Info("Bucketing stream connected")
+	atomic.StoreInt32(&b.streamConnected, 1)
+
+	err = readStreamEvents(resp.Body, b.applyStreamEvent)
+	if err == nil {
+		err = fmt.Errorf("stream closed by server")
+	}
+	return true, err
+}
+
+// applyStreamEvent applies a configuration pushed by the stream.
+// Only configuration and default message events carry a configuration, other events such as heartbeats are ignored
+func (b *Engine) applyStreamEvent(eventType string, data []byte) {
+	if eventType != streamConfigurationEvent && eventType != streamMessageEvent {
+		logger.Debug(fmt.Sprintf("Ignoring bucketing stream event of type %s", eventType))
+		return
+	}
+
+	// Streamed configurations are not signed, the event only triggers the load of the signed configuration
+	if b.signatureKey != nil {
+		logger.Info("Received configuration change from bucketing stream, loading signed configuration")
+		b.Load()
+		return
+	}
+
+	newConfig := &Configuration{}
+	err := json.Unmarshal(data, newConfig)
+	if err != nil {
+		logger.Error("Error when parsing streamed configuration", err)
+		b.recordLoad(err)
+		return
+	}
+
+	if err := ValidateConfiguration(newConfig); err != nil {
+		logger.Error("Invalid streamed configuration, keeping the previous one", err)
+		b.recordLoad(err)
+		return
+	}
+
+	logger.Info("Received configuration from bucketing stream")
+	b.setConfiguration(newConfig)
+	b.writeCache(newConfig)
+	b.recordLoad(nil)
+}
+
+// readStreamEvents reads Server-Sent Events and calls onEvent with the type and the data of each complete event
+func readStreamEvents(body io.Reader, onEvent func(eventType string, data []byte)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamEventSize)
+
+	eventType := streamMessageEvent
+	data := &bytes.Buffer{}
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		// An empty line dispatches the event
+		if len(line) == 0 {
+			if data.Len() > 0 {
+				onEvent(eventType, data.Bytes())
+				data = &bytes.Buffer{}
+			}
+			eventType = streamMessageEvent
+			continue
+		}
+
+		// Lines starting with a colon are comments, used as keep alive
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte{}
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(value)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func nextStreamBackoff(current time.Duration, max time.Duration) time.Duration {
+	next := current * 2
+	if next > max {
+		return max
+	}
+	return next
+}
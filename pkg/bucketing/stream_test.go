@@ -0,0 +1,98 @@
+package bucketing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStreamEvents(t *testing.T) {
+	body := strings.NewReader(": keep alive\n\nevent: configuration\ndata: {\"panic\":\ndata: true}\n\ndata: second\n\nevent: ping\ndata: {}\n\n")
+
+	events := []string{}
+	types := []string{}
+	err := readStreamEvents(body, func(eventType string, data []byte) {
+		types = append(types, eventType)
+		events = append(events, string(data))
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"{\"panic\":\ntrue}", "second", "{}"}, events)
+	assert.Equal(t, []string{"configuration", "message", "ping"}, types)
+}
+
+func TestApplyStreamEventTypes(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	engine.applyStreamEvent("configuration", []byte(`{"campaigns":[{"id":"streamed_cid"}]}`))
+	assert.Equal(t, "streamed_cid", engine.getConfig().Campaigns[0].ID)
+
+	// Heartbeats do not replace the configuration
+	engine.applyStreamEvent("ping", []byte(`{}`))
+	assert.Equal(t, 1, len(engine.getConfig().Campaigns))
+
+	engine.applyStreamEvent("message", []byte(`{}`))
+	assert.Equal(t, 0, len(engine.getConfig().Campaigns))
+}
+
+func TestNextStreamBackoff(t *testing.T) {
+	assert.Equal(t, 2*time.Second, nextStreamBackoff(1*time.Second, 10*time.Second))
+	assert.Equal(t, 10*time.Second, nextStreamBackoff(8*time.Second, 10*time.Second))
+}
+
+func TestStreaming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"panic\":true,\"campaigns\":[{\"id\":\"streamed_cid\"}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), Streaming(ts.URL))
+
+	for i := 0; i < 50 && !engine.IsStreaming(); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.True(t, engine.IsStreaming())
+
+	for i := 0; i < 50 && (engine.getConfig() == nil || !engine.getConfig().Panic); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	config := engine.getConfig()
+	assert.Equal(t, true, config.Panic)
+	assert.Equal(t, "streamed_cid", config.Campaigns[0].ID)
+
+	eg.TerminateAndWait()
+	assert.False(t, engine.IsStreaming())
+}
+
+func TestStreamingReconnect(t *testing.T) {
+	var nbCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&nbCalls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1), Streaming(ts.URL), StreamingBackoff(10*time.Millisecond, 20*time.Millisecond))
+
+	time.Sleep(200 * time.Millisecond)
+	eg.TerminateAndWait()
+
+	assert.False(t, engine.IsStreaming())
+	assert.True(t, atomic.LoadInt32(&nbCalls) > 2)
+}
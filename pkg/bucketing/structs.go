@@ -11,6 +11,7 @@ type TargetingOperator string
 
 // The different targeting operators
 const (
+	// NULL matches a context key that is not set, like NOT_EXISTS
 	NULL                   TargetingOperator = "NULL"
 	LOWER_THAN             TargetingOperator = "LOWER_THAN"
 	GREATER_THAN_OR_EQUALS TargetingOperator = "GREATER_THAN_OR_EQUALS"
@@ -22,8 +23,48 @@ const (
 	CONTAINS               TargetingOperator = "CONTAINS"
 	NOT_CONTAINS           TargetingOperator = "NOT_CONTAINS"
 	GREATER_THAN           TargetingOperator = "GREATER_THAN"
+	REGEX                  TargetingOperator = "REGEX"
+	NOT_REGEX              TargetingOperator = "NOT_REGEX"
+	IN                     TargetingOperator = "IN"
+	NOT_IN                 TargetingOperator = "NOT_IN"
+	EXISTS                 TargetingOperator = "EXISTS"
+	NOT_EXISTS             TargetingOperator = "NOT_EXISTS"
+
+	EQUALS_CASE_SENSITIVE       TargetingOperator = "EQUALS_CASE_SENSITIVE"
+	NOT_EQUALS_CASE_SENSITIVE   TargetingOperator = "NOT_EQUALS_CASE_SENSITIVE"
+	STARTS_WITH_CASE_SENSITIVE  TargetingOperator = "STARTS_WITH_CASE_SENSITIVE"
+	ENDS_WITH_CASE_SENSITIVE    TargetingOperator = "ENDS_WITH_CASE_SENSITIVE"
+	CONTAINS_CASE_SENSITIVE     TargetingOperator = "CONTAINS_CASE_SENSITIVE"
+	NOT_CONTAINS_CASE_SENSITIVE TargetingOperator = "NOT_CONTAINS_CASE_SENSITIVE"
+
+	VERSION_EQUALS                 TargetingOperator = "VERSION_EQUALS"
+	VERSION_NOT_EQUALS             TargetingOperator = "VERSION_NOT_EQUALS"
+	VERSION_LOWER_THAN             TargetingOperator = "VERSION_LOWER_THAN"
+	VERSION_LOWER_THAN_OR_EQUALS   TargetingOperator = "VERSION_LOWER_THAN_OR_EQUALS"
+	VERSION_GREATER_THAN           TargetingOperator = "VERSION_GREATER_THAN"
+	VERSION_GREATER_THAN_OR_EQUALS TargetingOperator = "VERSION_GREATER_THAN_OR_EQUALS"
+	VERSION_RANGE                  TargetingOperator = "VERSION_RANGE"
+
+	DATE_BEFORE  TargetingOperator = "DATE_BEFORE"
+	DATE_AFTER   TargetingOperator = "DATE_AFTER"
+	DATE_BETWEEN TargetingOperator = "DATE_BETWEEN"
+	DAY_OF_WEEK  TargetingOperator = "DAY_OF_WEEK"
+	HOUR_OF_DAY  TargetingOperator = "HOUR_OF_DAY"
 )
 
+// caseSensitiveOperators maps the case sensitive string operators to their case insensitive counterpart
+var caseSensitiveOperators = map[TargetingOperator]TargetingOperator{
+	EQUALS_CASE_SENSITIVE:       EQUALS,
+	NOT_EQUALS_CASE_SENSITIVE:   NOT_EQUALS,
+	STARTS_WITH_CASE_SENSITIVE:  STARTS_WITH,
+	ENDS_WITH_CASE_SENSITIVE:    ENDS_WITH,
+	CONTAINS_CASE_SENSITIVE:     CONTAINS,
+	NOT_CONTAINS_CASE_SENSITIVE: NOT_CONTAINS,
+}
+
+// TargetingTypeVersion is the targeting type for semantic version values
+const TargetingTypeVersion = "version"
+
 // EngineOptions represents the options for the Bucketing decision mode
 type EngineOptions struct {
 	// PollingInterval is the number of milliseconds between each poll. If -1, then no polling will be done
@@ -34,13 +75,24 @@ type EngineOptions struct {
 type Configuration struct {
 	Panic     bool        `json:"panic"`
 	Campaigns []*Campaign `json:"campaigns"`
+	Layers    []*Layer    `json:"layers,omitempty"`
+	Holdout   *Holdout    `json:"holdout,omitempty"`
 }
 
 // Campaign represents a bucketing campaign
 type Campaign struct {
-	ID              string            `json:"id"`
-	Type            string            `json:"type"`
-	VariationGroups []*VariationGroup `json:"variationGroups"`
+	ID                string            `json:"id"`
+	Type              string            `json:"type"`
+	VariationGroups   []*VariationGroup `json:"variationGroups"`
+	AllocationVersion int               `json:"allocationVersion,omitempty"`
+	Salt              string            `json:"salt,omitempty"`
+	BucketSpace       int               `json:"bucketSpace,omitempty"`
+	BucketingKey      string            `json:"bucketingKey,omitempty"`
+	TrafficAllocation *float64          `json:"trafficAllocation,omitempty"`
+	Priority          int               `json:"priority,omitempty"`
+	StartDate         *time.Time        `json:"startDate,omitempty"`
+	EndDate           *time.Time        `json:"endDate,omitempty"`
+	RolloutSteps      []*RolloutStep    `json:"rolloutSteps,omitempty"`
 }
 
 // VariationGroup represents a bucketing variation group
@@ -48,13 +100,15 @@ type VariationGroup struct {
 	ID         string           `json:"id"`
 	Targeting  TargetingWrapper `json:"targeting"`
 	Variations []*Variation     `json:"variations"`
+	StartDate  *time.Time       `json:"startDate,omitempty"`
+	EndDate    *time.Time       `json:"endDate,omitempty"`
 }
 
 // Variation represents a bucketing variation
 type Variation struct {
 	ID            string                         `json:"id"`
 	Modifications decision.APIClientModification `json:"modifications"`
-	Allocation    int                            `json:"allocation"`
+	Allocation    float64                        `json:"allocation"`
 	Reference     bool                           `json:"reference"`
 }
 
@@ -73,4 +127,6 @@ type Targeting struct {
 	Operator TargetingOperator `json:"operator"`
 	Key      string            `json:"key"`
 	Value    interface{}       `json:"value"`
+	Type     string            `json:"type,omitempty"`
+	TimeZone string            `json:"timeZone,omitempty"`
 }
@@ -2,12 +2,23 @@ package bucketing
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
+	"time"
 )
 
-// TargetingMatch returns true if a visitor ID and context match the variationGroup targeting
+var errKindMismatch = errors.New("Targeting and Context value kinds mismatch")
+
+// TargetingMatch returns true if a visitor ID and context match the variationGroup targeting, which is an OR of its
+// targeting groups, each being an AND of its targetings. fs_all_users matches every visitor and fs_users matches the
+// visitor ID against the targeting value or each value of a targeting list
 func TargetingMatch(variationGroup *VariationGroup, visitorID string, context map[string]interface{}) (bool, error) {
+	return targetingMatchAt(variationGroup, visitorID, context, time.Time{})
+}
+
+// targetingMatchAt is TargetingMatch with now used for CurrentTimeContextKey when the context does not set it, unless now is zero
+func targetingMatchAt(variationGroup *VariationGroup, visitorID string, context map[string]interface{}, now time.Time) (bool, error) {
 	globalMatch := false
 	for _, targetingGroup := range variationGroup.Targeting.TargetingGroups {
 		matchGroup := len(targetingGroup.Targetings) > 0
@@ -15,22 +26,23 @@ func TargetingMatch(variationGroup *VariationGroup, visitorID string, context ma
 			v, ok := context[targeting.Key]
 			switch targeting.Key {
 			case "fs_all_users":
-				return true, nil
+				continue
 			case "fs_users":
 				v = visitorID
 				ok = true
-			}
-
-			if ok {
-				matchTargeting, err := targetingMatchOperator(targeting.Operator, targeting.Value, v)
-				if err != nil {
-					return false, err
+			case CurrentTimeContextKey:
+				if !ok && !now.IsZero() {
+					v = currentTimeValue(targeting.Operator, now)
+					ok = true
 				}
+			}
 
-				matchGroup = matchGroup && matchTargeting
-			} else {
-				matchGroup = false
+			matchTargeting, err := targetingMatchPresence(targeting, v, ok)
+			if err != nil {
+				return false, err
 			}
+
+			matchGroup = matchGroup && matchTargeting
 		}
 		globalMatch = globalMatch || matchGroup
 	}
@@ -38,14 +50,75 @@ func TargetingMatch(variationGroup *VariationGroup, visitorID string, context ma
 	return globalMatch, nil
 }
 
+// targetingMatchPresence matches a targeting against a context value that may be missing.
+// EXISTS matches set keys, NOT_EXISTS and NULL match missing keys. For other operators, a missing key
+// matches negative operators only (e.g. a visitor without a country is NOT_EQUALS to any country), like the decision API
+func targetingMatchPresence(targeting *Targeting, contextValue interface{}, exists bool) (bool, error) {
+	exists = exists && contextValue != nil
+
+	switch targeting.Operator {
+	case EXISTS:
+		return exists, nil
+	case NOT_EXISTS, NULL:
+		return !exists, nil
+	}
+
+	if !exists {
+		return isNegativeOperator(targeting.Operator), nil
+	}
+
+	return targetingMatchValue(targeting, contextValue)
+}
+
+// targetingMatchValue returns true if the context value matches the targeting, comparing versions when the targeting is about a version
+func targetingMatchValue(targeting *Targeting, contextValue interface{}) (bool, error) {
+	if isDateOperator(targeting.Operator) {
+		return targetingMatchOperatorDate(targeting.Operator, targeting.Value, contextValue, targeting.TimeZone)
+	}
+
+	targetingValue, okTargeting := targeting.Value.(string)
+	contextValueString, okContext := contextValue.(string)
+
+	if okTargeting && okContext && isVersionTargeting(targeting) {
+		match, err := targetingMatchOperatorVersion(targeting.Operator, targetingValue, contextValueString)
+		// Keys that only look like versions fall back to string comparison if the values are not versions
+		if err == nil || targeting.Type == TargetingTypeVersion {
+			return match, err
+		}
+	}
+
+	return targetingMatchOperator(targeting.Operator, targeting.Value, contextValue)
+}
+
 func targetingMatchOperator(operator TargetingOperator, targetingValue interface{}, contextValue interface{}) (bool, error) {
 	match := false
 	var err error
 
-	isList := strings.Contains(reflect.TypeOf(targetingValue).String(), "[]")
-	// Except for targeting value of type list, check that context and targeting types are equals
-	if !isList && reflect.TypeOf(targetingValue) != reflect.TypeOf(contextValue) {
-		return false, errors.New("Targeting and Context value kinds mismatch")
+	// IN and NOT_IN compare the context value to each value of the targeting list
+	switch operator {
+	case IN, NOT_IN:
+		if _, ok := takeSliceArg(targetingValue); !ok {
+			targetingValue = []interface{}{targetingValue}
+		}
+		operator = map[TargetingOperator]TargetingOperator{IN: EQUALS, NOT_IN: NOT_EQUALS}[operator]
+	}
+
+	// List context values (e.g. visitor groups) match against each of their values
+	if contextList, ok := takeSliceArg(contextValue); ok {
+		return targetingMatchList(operator, contextList, func(v interface{}) (bool, error) {
+			return targetingMatchOperator(operator, targetingValue, v)
+		}), nil
+	}
+
+	if targetingList, ok := takeSliceArg(targetingValue); ok {
+		return targetingMatchList(operator, targetingList, func(v interface{}) (bool, error) {
+			return targetingMatchOperator(operator, v, contextValue)
+		}), nil
+	}
+
+	// Except for values of type list, check that context and targeting types are equals
+	if reflect.TypeOf(targetingValue) != reflect.TypeOf(contextValue) {
+		return false, errKindMismatch
 	}
 
 	switch targetingValue.(type) {
@@ -69,55 +142,76 @@ func targetingMatchOperator(operator TargetingOperator, targetingValue interface
 		match, err = targetingMatchOperatorNumber(operator, targetingValueCasted, contextValueCasted)
 	}
 
-	if isList {
-		targetingList, convOk := takeSliceArg(targetingValue)
-		if !convOk {
-			err = errors.New("Could not convert list targeting")
-			return match, err
-		}
-		if operator == NOT_EQUALS {
-			match = true
-			for _, v := range targetingList {
-				subValueMatch, err := targetingMatchOperator(operator, v, contextValue)
-				match = match && err == nil && subValueMatch
-			}
+	return match, err
+}
+
+// targetingMatchList matches each value of a list. Positive operators match if any value matches,
+// negative operators (e.g. NOT_EQUALS) match if all values match. Values raising an error do not match
+func targetingMatchList(operator TargetingOperator, values []interface{}, matchValue func(v interface{}) (bool, error)) bool {
+	negative := isNegativeOperator(operator)
+	for _, v := range values {
+		subValueMatch, err := matchValue(v)
+		subValueMatch = err == nil && subValueMatch
+		if negative && !subValueMatch {
+			return false
 		}
-		if operator == EQUALS {
-			for _, v := range targetingList {
-				subValueMatch, err := targetingMatchOperator(operator, v, contextValue)
-				match = match || (err == nil && subValueMatch)
-			}
+		if !negative && subValueMatch {
+			return true
 		}
 	}
+	return negative
+}
 
-	return match, err
+// isNegativeOperator returns true if the operator is the negation of another operator
+func isNegativeOperator(operator TargetingOperator) bool {
+	switch operator {
+	case NOT_EQUALS, NOT_CONTAINS, NOT_REGEX, NOT_IN, VERSION_NOT_EQUALS, NOT_EQUALS_CASE_SENSITIVE, NOT_CONTAINS_CASE_SENSITIVE:
+		return true
+	}
+	return false
 }
 
 func targetingMatchOperatorString(operator TargetingOperator, targetingValue string, contextValue string) (bool, error) {
+	switch operator {
+	case REGEX:
+		return regexMatch(targetingValue, contextValue)
+	case NOT_REGEX:
+		match, err := regexMatch(targetingValue, contextValue)
+		return !match && err == nil, err
+	case VERSION_EQUALS, VERSION_NOT_EQUALS, VERSION_LOWER_THAN, VERSION_LOWER_THAN_OR_EQUALS, VERSION_GREATER_THAN, VERSION_GREATER_THAN_OR_EQUALS, VERSION_RANGE:
+		return targetingMatchOperatorVersion(operator, targetingValue, contextValue)
+	}
+
+	if caseSensitiveOperator, ok := caseSensitiveOperators[operator]; ok {
+		return targetingMatchOperatorStringCase(caseSensitiveOperator, targetingValue, contextValue)
+	}
+
+	return targetingMatchOperatorStringCase(operator, strings.ToLower(targetingValue), strings.ToLower(contextValue))
+}
+
+// targetingMatchOperatorStringCase compares strings without changing their case
+func targetingMatchOperatorStringCase(operator TargetingOperator, targetingValue string, contextValue string) (bool, error) {
 	switch operator {
 	case LOWER_THAN:
-		return strings.ToLower(contextValue) < strings.ToLower(targetingValue), nil
+		return contextValue < targetingValue, nil
 	case GREATER_THAN:
-		return strings.ToLower(contextValue) > strings.ToLower(targetingValue), nil
+		return contextValue > targetingValue, nil
 	case LOWER_THAN_OR_EQUALS:
-		return strings.ToLower(contextValue) <= strings.ToLower(targetingValue), nil
+		return contextValue <= targetingValue, nil
 	case GREATER_THAN_OR_EQUALS:
-		return strings.ToLower(contextValue) >= strings.ToLower(targetingValue), nil
+		return contextValue >= targetingValue, nil
 	case EQUALS:
-		return strings.ToLower(contextValue) == strings.ToLower(targetingValue), nil
+		return contextValue == targetingValue, nil
 	case NOT_EQUALS:
-		return strings.ToLower(contextValue) != strings.ToLower(targetingValue), nil
+		return contextValue != targetingValue, nil
 	case STARTS_WITH:
-		return strings.HasPrefix(strings.ToLower(contextValue), strings.ToLower(targetingValue)), nil
+		return strings.HasPrefix(contextValue, targetingValue), nil
 	case ENDS_WITH:
-		return strings.HasSuffix(strings.ToLower(contextValue), strings.ToLower(targetingValue)), nil
+		return strings.HasSuffix(contextValue, targetingValue), nil
 	case CONTAINS:
-		return strings.Contains(strings.ToLower(contextValue), strings.ToLower(targetingValue)), nil
+		return strings.Contains(contextValue, targetingValue), nil
 	case NOT_CONTAINS:
-		return !strings.Contains(strings.ToLower(contextValue), strings.ToLower(targetingValue)), nil
-	// case "regex":
-	// 	match, err := regexp.MatchString(targetingValue, contextValue)
-	// 	return match, err
+		return !strings.Contains(contextValue, targetingValue), nil
 	default:
 		return false, errors.New("Operator not handled")
 	}
@@ -153,6 +247,201 @@ func targetingMatchOperatorBool(operator TargetingOperator, targetingValue bool,
 	}
 }
 
+// isVersionTargeting returns true if the targeting compares versions, either from its type or from its context key
+func isVersionTargeting(targeting *Targeting) bool {
+	return targeting.Type == TargetingTypeVersion || isVersionKey(targeting.Key)
+}
+
+// isVersionKey returns true if the context key is "version" or ends with a delimited version suffix
+// ("app_version", "os-version", "appVersion"). Keys like "conversion" are not versions
+func isVersionKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	return lowerKey == "version" ||
+		strings.HasSuffix(lowerKey, "_version") ||
+		strings.HasSuffix(lowerKey, "-version") ||
+		(len(key) > len("Version") && strings.HasSuffix(key, "Version"))
+}
+
+// targetingMatchOperatorVersion compares semantic versions. Range targeting values are space separated comparators
+// (e.g. ">=1.2.0 <2.0.0") and alternatives can be separated by "||"
+func targetingMatchOperatorVersion(operator TargetingOperator, targetingValue string, contextValue string) (bool, error) {
+	contextVersion, err := ParseVersion(contextValue)
+	if err != nil {
+		return false, err
+	}
+
+	if operator == VERSION_RANGE {
+		return versionMatchRange(targetingValue, contextVersion)
+	}
+
+	targetingVersion, err := ParseVersion(targetingValue)
+	if err != nil {
+		return false, err
+	}
+
+	return versionMatchOperator(operator, targetingVersion, contextVersion)
+}
+
+// versionMatchOperator compares a context version to a targeting version
+func versionMatchOperator(operator TargetingOperator, targetingVersion *Version, contextVersion *Version) (bool, error) {
+	c := contextVersion.Compare(targetingVersion)
+	switch operator {
+	case EQUALS, VERSION_EQUALS:
+		return c == 0, nil
+	case NOT_EQUALS, VERSION_NOT_EQUALS:
+		return c != 0, nil
+	case LOWER_THAN, VERSION_LOWER_THAN:
+		return c < 0, nil
+	case LOWER_THAN_OR_EQUALS, VERSION_LOWER_THAN_OR_EQUALS:
+		return c <= 0, nil
+	case GREATER_THAN, VERSION_GREATER_THAN:
+		return c > 0, nil
+	case GREATER_THAN_OR_EQUALS, VERSION_GREATER_THAN_OR_EQUALS:
+		return c >= 0, nil
+	default:
+		return false, fmt.Errorf("Operator %s not handled for versions", operator)
+	}
+}
+
+// versionMatchRange returns true if the version satisfies one of the range alternatives
+func versionMatchRange(versionRange string, version *Version) (bool, error) {
+	for _, alternative := range strings.Split(versionRange, "||") {
+		comparators := strings.Fields(alternative)
+		if len(comparators) == 0 {
+			return false, fmt.Errorf("Invalid version range %q", versionRange)
+		}
+
+		match := true
+		for _, comparator := range comparators {
+			operator, value := splitVersionComparator(comparator)
+			target, err := ParseVersion(value)
+			if err != nil {
+				return false, err
+			}
+
+			c := version.Compare(target)
+			switch operator {
+			case "=":
+				match = match && c == 0
+			case "!=":
+				match = match && c != 0
+			case "<":
+				match = match && c < 0
+			case "<=":
+				match = match && c <= 0
+			case ">":
+				match = match && c > 0
+			case ">=":
+				match = match && c >= 0
+			}
+		}
+
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func splitVersionComparator(comparator string) (string, string) {
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(comparator, op) {
+			return op, comparator[len(op):]
+		}
+	}
+	return "=", comparator
+}
+
+func isDateOperator(operator TargetingOperator) bool {
+	switch operator {
+	case DATE_BEFORE, DATE_AFTER, DATE_BETWEEN, DAY_OF_WEEK, HOUR_OF_DAY:
+		return true
+	}
+	return false
+}
+
+// targetingMatchOperatorDate compares date times. Day of week and hour of day are computed in the targeting time zone
+func targetingMatchOperatorDate(operator TargetingOperator, targetingValue interface{}, contextValue interface{}, timeZone string) (bool, error) {
+	loc, err := loadTimeZone(timeZone)
+	if err != nil {
+		return false, err
+	}
+
+	contextTime, err := parseTime(contextValue, loc)
+	if err != nil {
+		return false, err
+	}
+
+	matchTime, err := compileDateMatcher(operator, targetingValue, loc)
+	if err != nil {
+		return false, err
+	}
+
+	return matchTime(contextTime), nil
+}
+
+// compileDateMatcher parses a date targeting value and returns the function matching a context time against it
+func compileDateMatcher(operator TargetingOperator, targetingValue interface{}, loc *time.Location) (func(contextTime time.Time) bool, error) {
+	switch operator {
+	case DATE_BEFORE, DATE_AFTER:
+		targetingTime, err := parseTime(targetingValue, loc)
+		if err != nil {
+			return nil, err
+		}
+		if operator == DATE_BEFORE {
+			return targetingTime.After, nil
+		}
+		return targetingTime.Before, nil
+	case DATE_BETWEEN:
+		bounds, ok := takeSliceArg(targetingValue)
+		if !ok || len(bounds) != 2 {
+			return nil, fmt.Errorf("Targeting value %v must be a list of a start and an end date", targetingValue)
+		}
+		start, err := parseTime(bounds[0], loc)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTime(bounds[1], loc)
+		if err != nil {
+			return nil, err
+		}
+		return func(contextTime time.Time) bool {
+			return !contextTime.Before(start) && contextTime.Before(end)
+		}, nil
+	case DAY_OF_WEEK:
+		values, ok := takeSliceArg(targetingValue)
+		if !ok {
+			values = []interface{}{targetingValue}
+		}
+		days := map[time.Weekday]bool{}
+		for _, d := range values {
+			day, err := parseWeekday(d)
+			if err != nil {
+				return nil, err
+			}
+			days[day] = true
+		}
+		return func(contextTime time.Time) bool {
+			return days[contextTime.Weekday()]
+		}, nil
+	case HOUR_OF_DAY:
+		start, end, err := parseHourRange(targetingValue)
+		if err != nil {
+			return nil, err
+		}
+		return func(contextTime time.Time) bool {
+			hour := contextTime.Hour()
+			// Ranges such as 22-6 wrap around midnight
+			if start <= end {
+				return hour >= start && hour < end
+			}
+			return hour >= start || hour < end
+		}, nil
+	default:
+		return nil, errors.New("Operator not handled")
+	}
+}
+
 func takeSliceArg(arg interface{}) (out []interface{}, ok bool) {
 	slice, success := takeArg(arg, reflect.Slice)
 	if !success {
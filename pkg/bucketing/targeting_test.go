@@ -2,6 +2,7 @@ package bucketing
 
 import (
 	"testing"
+	"time"
 )
 
 func testTargetingNumber(operator TargetingOperator, targetingValue float64, value float64, t *testing.T, shouldMatch bool, shouldRaiseError bool) {
@@ -205,3 +206,153 @@ func TestTargetingMatch(t *testing.T) {
 		t.Error("Expected error as targeting and context value type do not match")
 	}
 }
+
+// TestRegexTargeting checks regex string targeting
+func TestRegexTargeting(t *testing.T) {
+	testTargetingString(REGEX, "^a.c$", "abc", t, true, false)
+	testTargetingString(REGEX, "^a.c$", "abcd", t, false, false)
+	testTargetingString(REGEX, "^a(c", "abc", t, false, true)
+
+	testTargetingString(NOT_REGEX, "^a.c$", "abc", t, false, false)
+	testTargetingString(NOT_REGEX, "^a.c$", "abcd", t, true, false)
+	testTargetingString(NOT_REGEX, "^a(c", "abc", t, false, true)
+
+	testTargetingNumber(REGEX, 1, 1, t, false, true)
+}
+
+// TestCaseSensitiveStringTargeting checks case sensitive string targeting
+func TestCaseSensitiveStringTargeting(t *testing.T) {
+	testTargetingString(EQUALS_CASE_SENSITIVE, "abc", "abc", t, true, false)
+	testTargetingString(EQUALS_CASE_SENSITIVE, "ABC", "abc", t, false, false)
+	testTargetingString(NOT_EQUALS_CASE_SENSITIVE, "ABC", "abc", t, true, false)
+	testTargetingString(CONTAINS_CASE_SENSITIVE, "B", "abc", t, false, false)
+	testTargetingString(NOT_CONTAINS_CASE_SENSITIVE, "B", "abc", t, true, false)
+	testTargetingString(STARTS_WITH_CASE_SENSITIVE, "A", "abc", t, false, false)
+	testTargetingString(STARTS_WITH_CASE_SENSITIVE, "a", "abc", t, true, false)
+	testTargetingString(ENDS_WITH_CASE_SENSITIVE, "C", "abc", t, false, false)
+}
+
+// TestListTargeting checks list semantics for all operators
+func TestListTargeting(t *testing.T) {
+	testTargetingListString(CONTAINS, []string{"xyz", "bc"}, "abc", t, true, false)
+	testTargetingListString(CONTAINS, []string{"xyz", "de"}, "abc", t, false, false)
+	testTargetingListString(NOT_CONTAINS, []string{"xyz", "de"}, "abc", t, true, false)
+	testTargetingListString(NOT_CONTAINS, []string{"xyz", "bc"}, "abc", t, false, false)
+	testTargetingListString(STARTS_WITH, []string{"x", "A"}, "abc", t, true, false)
+	testTargetingListString(IN, []string{"abc", "bcd"}, "abc", t, true, false)
+	testTargetingListString(NOT_IN, []string{"abc", "bcd"}, "abc", t, false, false)
+	testTargetingListString(NOT_IN, []string{"abc", "bcd"}, "xyz", t, true, false)
+
+	testTargetingCast(IN, "abc", "abc", t, true, false)
+	testTargetingCast(GREATER_THAN, []interface{}{100.0, 5.0}, 10.0, t, true, false)
+	testTargetingCast(GREATER_THAN, []interface{}{100.0, 50.0}, 10.0, t, false, false)
+	testTargetingCast(LOWER_THAN, []interface{}{"abc", 100.0}, 10.0, t, true, false)
+}
+
+// TestListContextTargeting checks targeting against list context values
+func TestListContextTargeting(t *testing.T) {
+	groups := []interface{}{"admin", "beta_testers"}
+
+	testTargetingCast(EQUALS, "beta_testers", groups, t, true, false)
+	testTargetingCast(EQUALS, "staff", groups, t, false, false)
+	testTargetingCast(NOT_EQUALS, "staff", groups, t, true, false)
+	testTargetingCast(NOT_EQUALS, "admin", groups, t, false, false)
+	testTargetingCast(STARTS_WITH, "beta", groups, t, true, false)
+	testTargetingCast(IN, []interface{}{"staff", "admin"}, groups, t, true, false)
+	testTargetingCast(NOT_IN, []interface{}{"staff", "admin"}, groups, t, false, false)
+	testTargetingCast(NOT_IN, []interface{}{"staff"}, groups, t, true, false)
+	testTargetingCast(GREATER_THAN, 10.0, []interface{}{1.0, 20.0}, t, true, false)
+	testTargetingCast(EQUALS, "admin", []interface{}{}, t, false, false)
+	testTargetingCast(NOT_EQUALS, "admin", []interface{}{}, t, true, false)
+}
+
+func testTargetingPresence(operator TargetingOperator, context map[string]interface{}, t *testing.T, shouldMatch bool) {
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{
+				Targetings: []*Targeting{{
+					Operator: operator,
+					Key:      "country",
+					Value:    "FR",
+				}},
+			}},
+		},
+	}
+
+	match, err := TargetingMatch(vg, testVID, context)
+	if err != nil || match != shouldMatch {
+		t.Errorf("Targeting presence %v not working - context : %v, match : %v, err: %v", operator, context, match, err)
+	}
+}
+
+// TestMissingKeyTargeting checks the operators behaviour when the context key is missing
+func TestMissingKeyTargeting(t *testing.T) {
+	missing := map[string]interface{}{}
+	nilValue := map[string]interface{}{"country": nil}
+	set := map[string]interface{}{"country": "UK"}
+
+	testTargetingPresence(EXISTS, missing, t, false)
+	testTargetingPresence(EXISTS, nilValue, t, false)
+	testTargetingPresence(EXISTS, set, t, true)
+
+	testTargetingPresence(NOT_EXISTS, missing, t, true)
+	testTargetingPresence(NOT_EXISTS, nilValue, t, true)
+	testTargetingPresence(NOT_EXISTS, set, t, false)
+
+	testTargetingPresence(NULL, missing, t, true)
+	testTargetingPresence(NULL, set, t, false)
+
+	testTargetingPresence(EQUALS, missing, t, false)
+	testTargetingPresence(CONTAINS, missing, t, false)
+	testTargetingPresence(IN, missing, t, false)
+	testTargetingPresence(NOT_EQUALS, missing, t, true)
+	testTargetingPresence(NOT_CONTAINS, missing, t, true)
+	testTargetingPresence(NOT_IN, missing, t, true)
+	testTargetingPresence(NOT_EQUALS, set, t, true)
+}
+
+func testTargetingGroups(groups [][]*Targeting, visitorID string, context map[string]interface{}, t *testing.T, shouldMatch bool) {
+	vg := &VariationGroup{}
+	for _, targetings := range groups {
+		vg.Targeting.TargetingGroups = append(vg.Targeting.TargetingGroups, &TargetingGroup{Targetings: targetings})
+	}
+
+	match, err := TargetingMatch(vg, visitorID, context)
+	if err != nil || match != shouldMatch {
+		t.Errorf("Targeting groups %v not working - visitor : %s, context : %v, match : %v, err : %v", groups, visitorID, context, match, err)
+	}
+
+	match, err = compileVariationGroup(vg)(visitorID, context, time.Time{})
+	if err != nil || match != shouldMatch {
+		t.Errorf("Compiled targeting groups %v not working - visitor : %s, context : %v, match : %v, err : %v", groups, visitorID, context, match, err)
+	}
+}
+
+// TestAllUsersTargeting checks that fs_all_users is a match-all term of its targeting group
+func TestAllUsersTargeting(t *testing.T) {
+	allUsers := &Targeting{Operator: EQUALS, Key: "fs_all_users", Value: ""}
+	countryFR := &Targeting{Operator: EQUALS, Key: "country", Value: "fr"}
+
+	testTargetingGroups([][]*Targeting{{allUsers}}, testVID, map[string]interface{}{}, t, true)
+	testTargetingGroups([][]*Targeting{{allUsers, countryFR}}, testVID, map[string]interface{}{"country": "FR"}, t, true)
+	testTargetingGroups([][]*Targeting{{allUsers, countryFR}}, testVID, map[string]interface{}{"country": "us"}, t, false)
+	testTargetingGroups([][]*Targeting{{countryFR, allUsers}}, testVID, map[string]interface{}{"country": "us"}, t, false)
+	testTargetingGroups([][]*Targeting{{countryFR, allUsers}}, testVID, map[string]interface{}{}, t, false)
+
+	// Targeting groups are still OR linked
+	testTargetingGroups([][]*Targeting{{countryFR}, {allUsers}}, testVID, map[string]interface{}{"country": "us"}, t, true)
+	testTargetingGroups([][]*Targeting{{allUsers, countryFR}, {countryFR}}, testVID, map[string]interface{}{"country": "us"}, t, false)
+}
+
+// TestUsersTargeting checks that fs_users matches the visitor ID against single and list values
+func TestUsersTargeting(t *testing.T) {
+	testTargetingGroups([][]*Targeting{{{Operator: EQUALS, Key: "fs_users", Value: "vid_1"}}}, "vid_1", map[string]interface{}{}, t, true)
+	testTargetingGroups([][]*Targeting{{{Operator: EQUALS, Key: "fs_users", Value: []interface{}{"vid_1", "vid_2"}}}}, "vid_2", map[string]interface{}{}, t, true)
+	testTargetingGroups([][]*Targeting{{{Operator: EQUALS, Key: "fs_users", Value: []interface{}{"vid_1", "vid_2"}}}}, "vid_3", map[string]interface{}{}, t, false)
+	testTargetingGroups([][]*Targeting{{{Operator: IN, Key: "fs_users", Value: []string{"vid_1", "vid_2"}}}}, "vid_1", map[string]interface{}{}, t, true)
+	testTargetingGroups([][]*Targeting{{{Operator: NOT_EQUALS, Key: "fs_users", Value: []interface{}{"vid_1", "vid_2"}}}}, "vid_1", map[string]interface{}{}, t, false)
+	testTargetingGroups([][]*Targeting{{{Operator: NOT_IN, Key: "fs_users", Value: []interface{}{"vid_1", "vid_2"}}}}, "vid_3", map[string]interface{}{}, t, true)
+
+	// The visitor ID is used even if the context sets fs_users
+	testTargetingGroups([][]*Targeting{{{Operator: EQUALS, Key: "fs_users", Value: "vid_1"}}}, "vid_2", map[string]interface{}{"fs_users": "vid_1"}, t, false)
+}
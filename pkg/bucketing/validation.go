@@ -0,0 +1,250 @@
+package bucketing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownOperators lists the targeting operators handled by the engine
+var knownOperators = map[TargetingOperator]bool{
+	NULL: true, EXISTS: true, NOT_EXISTS: true,
+	LOWER_THAN: true, LOWER_THAN_OR_EQUALS: true, GREATER_THAN: true, GREATER_THAN_OR_EQUALS: true,
+	EQUALS: true, NOT_EQUALS: true, IN: true, NOT_IN: true,
+	STARTS_WITH: true, ENDS_WITH: true, CONTAINS: true, NOT_CONTAINS: true,
+	EQUALS_CASE_SENSITIVE: true, NOT_EQUALS_CASE_SENSITIVE: true, STARTS_WITH_CASE_SENSITIVE: true,
+	ENDS_WITH_CASE_SENSITIVE: true, CONTAINS_CASE_SENSITIVE: true, NOT_CONTAINS_CASE_SENSITIVE: true,
+	REGEX: true, NOT_REGEX: true,
+	VERSION_EQUALS: true, VERSION_NOT_EQUALS: true, VERSION_LOWER_THAN: true, VERSION_LOWER_THAN_OR_EQUALS: true,
+	VERSION_GREATER_THAN: true, VERSION_GREATER_THAN_OR_EQUALS: true, VERSION_RANGE: true,
+	DATE_BEFORE: true, DATE_AFTER: true, DATE_BETWEEN: true, DAY_OF_WEEK: true, HOUR_OF_DAY: true,
+}
+
+// ValidationError represents an invalid element of a bucketing configuration
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s : %s", e.Path, e.Message)
+}
+
+// ValidationErrors represents the list of errors of an invalid bucketing configuration
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("Invalid bucketing configuration : %s", strings.Join(messages, ", "))
+}
+
+// ValidateConfiguration checks a bucketing configuration and returns nil if it is valid, or ValidationErrors listing its errors
+func ValidateConfiguration(config *Configuration) error {
+	errs := ValidationErrors{}
+	add := func(path string, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if config == nil {
+		add("configuration", "configuration is empty")
+		return errs
+	}
+
+	campaignIDs := map[string]bool{}
+	bucketingKeys := map[string]string{}
+	for i, c := range config.Campaigns {
+		path := fmt.Sprintf("campaigns[%d]", i)
+		if c == nil {
+			add(path, "campaign is empty")
+			continue
+		}
+		if c.ID == "" {
+			add(path, "campaign ID is empty")
+		} else if campaignIDs[c.ID] {
+			add(path, "campaign ID %s is duplicated", c.ID)
+		}
+		campaignIDs[c.ID] = true
+		bucketingKeys[c.ID] = c.BucketingKey
+
+		if !validAllocationVersion(c.AllocationVersion) {
+			add(path, "allocation version %d is unknown", c.AllocationVersion)
+		}
+		if c.BucketSpace < 0 || c.BucketSpace > maxBucketSpace {
+			add(path, "bucket space %d is not between 0 and %d", c.BucketSpace, maxBucketSpace)
+		}
+		if !validateSchedule(c.StartDate, c.EndDate) {
+			add(path, "end date %v is not after start date %v", *c.EndDate, *c.StartDate)
+		}
+		if err := ValidateRollout(c); err != nil {
+			add(path, "%v", err)
+		}
+		if c.TrafficAllocation != nil && (*c.TrafficAllocation < 0 || *c.TrafficAllocation > 100) {
+			add(path, "traffic allocation %v is not between 0 and 100", *c.TrafficAllocation)
+		}
+
+		for j, vg := range c.VariationGroups {
+			vgPath := fmt.Sprintf("%s.variationGroups[%d]", path, j)
+			if vg == nil {
+				add(vgPath, "variation group is empty")
+				continue
+			}
+			if vg.ID == "" {
+				add(vgPath, "variation group ID is empty")
+			}
+			if !validateSchedule(vg.StartDate, vg.EndDate) {
+				add(vgPath, "end date %v is not after start date %v", *vg.EndDate, *vg.StartDate)
+			}
+			variationsValid := true
+			for k, v := range vg.Variations {
+				if v == nil {
+					add(fmt.Sprintf("%s.variations[%d]", vgPath, k), "variation is empty")
+					variationsValid = false
+				}
+			}
+			if variationsValid {
+				if err := ValidateAllocation(vg, c.BucketSpace); err != nil {
+					add(vgPath, "%v", err)
+				}
+			}
+
+			for k, tg := range vg.Targeting.TargetingGroups {
+				if tg == nil {
+					add(fmt.Sprintf("%s.targetingGroups[%d]", vgPath, k), "targeting group is empty")
+					continue
+				}
+				for l, t := range tg.Targetings {
+					tPath := fmt.Sprintf("%s.targetingGroups[%d].targetings[%d]", vgPath, k, l)
+					if err := ValidateTargeting(t); err != nil {
+						add(tPath, "%v", err)
+					}
+				}
+			}
+		}
+	}
+
+	if config.Holdout != nil {
+		if err := ValidateHoldout(config.Holdout); err != nil {
+			add("holdout", "%v", err)
+		}
+	}
+
+	for i, l := range config.Layers {
+		path := fmt.Sprintf("layers[%d]", i)
+		if l == nil {
+			add(path, "layer is empty")
+			continue
+		}
+		allocationsValid := true
+		layerCampaignID := ""
+		for j, a := range l.Allocations {
+			if a == nil {
+				add(fmt.Sprintf("%s.allocations[%d]", path, j), "layer allocation is empty")
+				allocationsValid = false
+				continue
+			}
+			if !campaignIDs[a.CampaignID] {
+				add(path, "campaign %s does not exist", a.CampaignID)
+				continue
+			}
+
+			// Campaigns of a layer are mutually exclusive only if they bucket visitors by the same key
+			if layerCampaignID == "" {
+				layerCampaignID = a.CampaignID
+			} else if bucketingKeys[a.CampaignID] != bucketingKeys[layerCampaignID] {
+				add(path, "campaigns %s and %s use different bucketing keys", layerCampaignID, a.CampaignID)
+			}
+		}
+		if allocationsValid {
+			if err := ValidateLayer(l); err != nil {
+				add(path, "%v", err)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateTargeting checks that the targeting operator is known and that its value can be evaluated with it
+func ValidateTargeting(targeting *Targeting) error {
+	if targeting == nil {
+		return fmt.Errorf("targeting is empty")
+	}
+
+	if !knownOperators[targeting.Operator] {
+		return fmt.Errorf("operator %q is unknown", targeting.Operator)
+	}
+
+	switch targeting.Operator {
+	case NULL, EXISTS, NOT_EXISTS:
+		return nil
+	}
+
+	if targeting.Value == nil {
+		return fmt.Errorf("value is empty for operator %s", targeting.Operator)
+	}
+
+	if isDateOperator(targeting.Operator) {
+		_, err := targetingMatchOperatorDate(targeting.Operator, targeting.Value, 0.0, targeting.TimeZone)
+		return err
+	}
+
+	values, ok := takeSliceArg(targeting.Value)
+	if !ok {
+		values = []interface{}{targeting.Value}
+	}
+
+	for _, v := range values {
+		if err := validateTargetingValue(targeting, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTargetingValue checks a single targeting value against a sample context value of the same type
+func validateTargetingValue(targeting *Targeting, value interface{}) error {
+	var sample interface{}
+	switch v := value.(type) {
+	case string:
+		if targeting.Operator == REGEX || targeting.Operator == NOT_REGEX {
+			_, err := newRegex(v)
+			return err
+		}
+		if strings.HasPrefix(string(targeting.Operator), "VERSION_") || targeting.Type == TargetingTypeVersion {
+			if targeting.Operator == VERSION_RANGE {
+				_, err := versionMatchRange(v, &Version{})
+				return err
+			}
+			_, err := ParseVersion(v)
+			return err
+		}
+		sample = ""
+	case float64:
+		sample = 0.0
+	case int:
+		sample = 0
+	case bool:
+		sample = false
+	default:
+		return fmt.Errorf("value %v of type %T is not supported", value, value)
+	}
+
+	operator := targeting.Operator
+	switch operator {
+	case IN:
+		operator = EQUALS
+	case NOT_IN:
+		operator = NOT_EQUALS
+	}
+
+	_, err := targetingMatchOperator(operator, value, sample)
+	if err != nil {
+		return fmt.Errorf("operator %s cannot be used with value %v : %v", targeting.Operator, value, err)
+	}
+	return nil
+}
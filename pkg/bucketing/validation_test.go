@@ -0,0 +1,109 @@
+package bucketing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abtasty/flagship-go-sdk/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createValidationTestConfig(targetings ...*Targeting) *Configuration {
+	return &Configuration{
+		Campaigns: []*Campaign{{
+			ID: "test_cid",
+			VariationGroups: []*VariationGroup{{
+				ID: "test_vgid",
+				Targeting: TargetingWrapper{
+					TargetingGroups: []*TargetingGroup{{
+						Targetings: targetings,
+					}},
+				},
+				Variations: []*Variation{{ID: "1", Allocation: 50}, {ID: "2", Allocation: 50}},
+			}},
+		}},
+	}
+}
+
+func testValidateTargeting(targeting *Targeting, t *testing.T, shouldBeValid bool) {
+	err := ValidateTargeting(targeting)
+	if (err == nil) != shouldBeValid {
+		t.Errorf("Targeting validation not working - targeting : %v, err : %v", targeting, err)
+	}
+}
+
+func TestValidateTargeting(t *testing.T) {
+	testValidateTargeting(&Targeting{Operator: EQUALS, Key: "k", Value: "abc"}, t, true)
+	testValidateTargeting(&Targeting{Operator: GREATER_THAN, Key: "k", Value: 10.0}, t, true)
+	testValidateTargeting(&Targeting{Operator: IN, Key: "k", Value: []interface{}{"a", "b"}}, t, true)
+	testValidateTargeting(&Targeting{Operator: EXISTS, Key: "k"}, t, true)
+	testValidateTargeting(&Targeting{Operator: REGEX, Key: "k", Value: "^a+$"}, t, true)
+	testValidateTargeting(&Targeting{Operator: VERSION_RANGE, Key: "k", Value: ">=1.0.0 <2.0.0"}, t, true)
+	testValidateTargeting(&Targeting{Operator: DAY_OF_WEEK, Key: "k", Value: []interface{}{"saturday"}, TimeZone: "Europe/Paris"}, t, true)
+
+	testValidateTargeting(nil, t, false)
+	testValidateTargeting(&Targeting{Operator: "UNKNOWN", Key: "k", Value: "abc"}, t, false)
+	testValidateTargeting(&Targeting{Operator: EQUALS, Key: "k"}, t, false)
+	testValidateTargeting(&Targeting{Operator: EQUALS, Key: "k", Value: map[string]interface{}{}}, t, false)
+	testValidateTargeting(&Targeting{Operator: CONTAINS, Key: "k", Value: true}, t, false)
+	testValidateTargeting(&Targeting{Operator: STARTS_WITH, Key: "k", Value: []interface{}{"a", 1.0}}, t, false)
+	testValidateTargeting(&Targeting{Operator: REGEX, Key: "k", Value: "^a(+$"}, t, false)
+	testValidateTargeting(&Targeting{Operator: VERSION_EQUALS, Key: "k", Value: "abc"}, t, false)
+	testValidateTargeting(&Targeting{Operator: HOUR_OF_DAY, Key: "k", Value: "25-3"}, t, false)
+	testValidateTargeting(&Targeting{Operator: DAY_OF_WEEK, Key: "k", Value: "saturday", TimeZone: "Nowhere/City"}, t, false)
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	// A valid configuration returns an untyped nil error
+	var err error = ValidateConfiguration(createValidationTestConfig(&Targeting{Operator: EQUALS, Key: "k", Value: "abc"}))
+	assert.True(t, err == nil)
+	assert.NotNil(t, ValidateConfiguration(nil))
+
+	config := createValidationTestConfig(&Targeting{Operator: "UNKNOWN", Key: "k", Value: "abc"})
+	config.Campaigns[0].VariationGroups[0].Variations[1].Allocation = 60
+	config.Campaigns = append(config.Campaigns, &Campaign{ID: "test_cid"})
+	config.Layers = []*Layer{{ID: "layer", Allocations: []*LayerAllocation{{CampaignID: "unknown_cid", Allocation: 10}}}}
+
+	errs := ValidateConfiguration(config).(ValidationErrors)
+	assert.Equal(t, 4, len(errs))
+	assert.Equal(t, "campaigns[0].variationGroups[0]", errs[0].Path)
+	assert.Equal(t, "campaigns[0].variationGroups[0].targetingGroups[0].targetings[0]", errs[1].Path)
+	assert.Equal(t, "campaigns[1]", errs[2].Path)
+	assert.Equal(t, "layers[0]", errs[3].Path)
+	assert.Contains(t, errs.Error(), "Invalid bucketing configuration")
+}
+
+func TestValidateConfigurationNullEntries(t *testing.T) {
+	config := &Configuration{}
+	err := json.Unmarshal([]byte(`{
+		"campaigns": [{"id": "test_cid", "variationGroups": [{"id": "test_vgid", "variations": [null]}]}],
+		"layers": [{"id": "layer", "allocations": [null]}]
+	}`), config)
+	assert.Nil(t, err)
+
+	errs := ValidateConfiguration(config).(ValidationErrors)
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "campaigns[0].variationGroups[0].variations[0]", errs[0].Path)
+	assert.Equal(t, "layers[0].allocations[0]", errs[1].Path)
+}
+
+func TestLoadInvalidConfiguration(t *testing.T) {
+	eg := utils.NewExecGroup(context.Background())
+	engine, _ := NewEngine(testEnvID, eg, PollingInterval(-1))
+
+	engine.apiClient = NewAPIClientMock(testEnvID, createValidationTestConfig(&Targeting{Operator: EQUALS, Key: "k", Value: "abc"}), 200)
+	err := engine.Load()
+	assert.Nil(t, err)
+
+	engine.apiClient = NewAPIClientMock(testEnvID, createValidationTestConfig(&Targeting{Operator: "UNKNOWN", Key: "k", Value: "abc"}), 200)
+	err = engine.Load()
+	assert.NotNil(t, err)
+
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(errs))
+
+	// The previous valid configuration is kept
+	assert.Equal(t, EQUALS, engine.getConfig().Campaigns[0].VariationGroups[0].Targeting.TargetingGroups[0].Targetings[0].Operator)
+}
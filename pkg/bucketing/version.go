@@ -0,0 +1,107 @@
+package bucketing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents a parsed semantic version
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease []string
+}
+
+// ParseVersion parses a semantic version such as "1.10.0", "v2.0" or "1.0.0-beta.2+build.5".
+// Missing minor and patch numbers default to 0, build metadata is ignored
+func ParseVersion(value string) (*Version, error) {
+	v := strings.TrimSpace(value)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+
+	version := &Version{}
+	if i := strings.Index(v, "-"); i >= 0 {
+		if i == len(v)-1 {
+			return nil, fmt.Errorf("Invalid version %q : empty pre-release", value)
+		}
+		version.PreRelease = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("Invalid version %q : too many numbers", value)
+	}
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Invalid version %q : %q is not a number", value, p)
+		}
+		*numbers[i] = n
+	}
+
+	return version, nil
+}
+
+// Compare returns -1, 0 or 1 if the version is lower, equal or greater than the other version
+func (v *Version) Compare(other *Version) int {
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// A version without pre-release has a higher precedence
+	switch {
+	case len(v.PreRelease) == 0 && len(other.PreRelease) == 0:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(other.PreRelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.PreRelease) && i < len(other.PreRelease); i++ {
+		if c := comparePreRelease(v.PreRelease[i], other.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.PreRelease), len(other.PreRelease))
+}
+
+// comparePreRelease compares pre-release identifiers: numeric identifiers are compared numerically and have a lower precedence than alphanumeric ones
+func comparePreRelease(a string, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package bucketing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testVersionCompare(a string, b string, expected int, t *testing.T) {
+	va, err := ParseVersion(a)
+	assert.Nil(t, err)
+	vb, err := ParseVersion(b)
+	assert.Nil(t, err)
+
+	if c := va.Compare(vb); c != expected {
+		t.Errorf("Version compare not working - %s vs %s : expected %d, got %d", a, b, expected, c)
+	}
+}
+
+func testTargetingVersion(operator TargetingOperator, targetingValue string, value string, t *testing.T, shouldMatch bool, shouldRaiseError bool) {
+	match, err := targetingMatchOperatorVersion(operator, targetingValue, value)
+
+	if ((err != nil && !shouldRaiseError) || (shouldRaiseError && err == nil)) || (match != shouldMatch) {
+		t.Errorf("Targeting version %v not working - tv : %v, v: %v, match : %v, err: %v", operator, targetingValue, value, match, err)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("v1.10.2-beta.1+build.5")
+	assert.Nil(t, err)
+	assert.Equal(t, &Version{Major: 1, Minor: 10, Patch: 2, PreRelease: []string{"beta", "1"}}, v)
+
+	v, err = ParseVersion("2.1")
+	assert.Nil(t, err)
+	assert.Equal(t, &Version{Major: 2, Minor: 1}, v)
+
+	_, err = ParseVersion("1.2.3.4")
+	assert.NotNil(t, err)
+	_, err = ParseVersion("1.a")
+	assert.NotNil(t, err)
+	_, err = ParseVersion("1.0.0-")
+	assert.NotNil(t, err)
+	_, err = ParseVersion("")
+	assert.NotNil(t, err)
+}
+
+func TestVersionCompare(t *testing.T) {
+	testVersionCompare("1.10.0", "1.9.0", 1, t)
+	testVersionCompare("1.9.0", "1.10.0", -1, t)
+	testVersionCompare("1.0", "1.0.0", 0, t)
+	testVersionCompare("1.0.0-alpha", "1.0.0", -1, t)
+	testVersionCompare("1.0.0-alpha", "1.0.0-alpha.1", -1, t)
+	testVersionCompare("1.0.0-alpha.1", "1.0.0-alpha.beta", -1, t)
+	testVersionCompare("1.0.0-beta.2", "1.0.0-beta.11", -1, t)
+	testVersionCompare("1.0.0-rc.1", "1.0.0-beta.11", 1, t)
+	testVersionCompare("1.0.0+build1", "1.0.0+build2", 0, t)
+}
+
+func TestVersionTargeting(t *testing.T) {
+	testTargetingVersion(VERSION_GREATER_THAN, "1.9.0", "1.10.0", t, true, false)
+	testTargetingVersion(VERSION_LOWER_THAN, "1.9.0", "1.10.0", t, false, false)
+	testTargetingVersion(VERSION_LOWER_THAN_OR_EQUALS, "1.10", "1.10.0", t, true, false)
+	testTargetingVersion(VERSION_GREATER_THAN_OR_EQUALS, "2.0.0", "2.0.0-rc.1", t, false, false)
+	testTargetingVersion(VERSION_EQUALS, "2.0.0", "v2.0.0", t, true, false)
+	testTargetingVersion(VERSION_NOT_EQUALS, "2.0.0", "2.0.1", t, true, false)
+	testTargetingVersion(EQUALS, "2.0.0", "2.0", t, true, false)
+	testTargetingVersion(CONTAINS, "2.0.0", "2.0", t, false, true)
+	testTargetingVersion(EQUALS, "2.0.0", "abc", t, false, true)
+
+	testTargetingVersion(VERSION_RANGE, ">=1.2.0 <2.0.0", "1.10.3", t, true, false)
+	testTargetingVersion(VERSION_RANGE, ">=1.2.0 <2.0.0", "2.0.0", t, false, false)
+	testTargetingVersion(VERSION_RANGE, "<1.0.0 || >=3.0.0", "3.1.0", t, true, false)
+	testTargetingVersion(VERSION_RANGE, "<1.0.0 || >=3.0.0", "2.1.0", t, false, false)
+	testTargetingVersion(VERSION_RANGE, "1.2.3", "1.2.3", t, true, false)
+	testTargetingVersion(VERSION_RANGE, ">=abc", "1.2.3", t, false, true)
+
+	testTargetingString(VERSION_GREATER_THAN, "1.9.0", "1.10.0", t, true, false)
+}
+
+func TestVersionTargetingMatch(t *testing.T) {
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{
+				Targetings: []*Targeting{{
+					Operator: GREATER_THAN,
+					Key:      "app_version",
+					Value:    "1.9.0",
+				}},
+			}},
+		},
+	}
+
+	match, err := TargetingMatch(vg, testVID, map[string]interface{}{"app_version": "1.10.0"})
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	// Non version values fall back to string comparison for keys looking like versions
+	match, err = TargetingMatch(vg, testVID, map[string]interface{}{"app_version": "abc"})
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	vg.Targeting.TargetingGroups[0].Targetings[0].Key = "release"
+	vg.Targeting.TargetingGroups[0].Targetings[0].Type = TargetingTypeVersion
+
+	match, err = TargetingMatch(vg, testVID, map[string]interface{}{"release": "1.10.0"})
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	_, err = TargetingMatch(vg, testVID, map[string]interface{}{"release": "abc"})
+	assert.NotNil(t, err)
+}
+
+func TestIsVersionKey(t *testing.T) {
+	for _, key := range []string{"version", "Version", "app_version", "OS_VERSION", "os-version", "appVersion"} {
+		assert.True(t, isVersionKey(key), key)
+	}
+	for _, key := range []string{"conversion", "subversion", "aversion", "versions", "version_name"} {
+		assert.False(t, isVersionKey(key), key)
+	}
+
+	// Keys that only end with "version" keep the string comparison
+	vg := &VariationGroup{
+		Targeting: TargetingWrapper{
+			TargetingGroups: []*TargetingGroup{{
+				Targetings: []*Targeting{{Operator: GREATER_THAN, Key: "conversion", Value: "2"}},
+			}},
+		},
+	}
+	match, err := TargetingMatch(vg, testVID, map[string]interface{}{"conversion": "10"})
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	match, err = compileVariationGroup(vg)(testVID, map[string]interface{}{"conversion": "10"}, time.Time{})
+	assert.Nil(t, err)
+	assert.False(t, match)
+}
@@ -55,22 +55,67 @@ func (c *FlagshipClient) init() {
 func validateContext(context map[string]interface{}) []error {
 	errorList := []error{}
 	for key, val := range context {
-		_, okBool := val.(bool)
-		_, okString := val.(string)
-		_, okFloat64 := val.(float64)
-		intVal, okInt := val.(int)
+		castVal, ok := validateContextValue(val)
 
-		if !okBool && !okString && !okFloat64 && !okInt {
-			errorList = append(errorList, fmt.Errorf("Value %v not handled for key %s. Type must be one of string, bool or number (int or float64)", val, key))
+		if !ok {
+			errorList = append(errorList, fmt.Errorf("Value %v not handled for key %s. Type must be one of string, bool or number (int or float64), or a list of these types", val, key))
+			continue
 		}
 
-		if okInt {
-			context[key] = float64(intVal)
-		}
+		context[key] = castVal
 	}
 	return errorList
 }
 
+// validateContextValue checks a context value type and converts integers to float64
+func validateContextValue(val interface{}) (interface{}, bool) {
+	switch v := val.(type) {
+	case bool, string, float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list, true
+	case []int:
+		list := make([]interface{}, len(v))
+		for i, n := range v {
+			list[i] = float64(n)
+		}
+		return list, true
+	case []float64:
+		list := make([]interface{}, len(v))
+		for i, n := range v {
+			list[i] = n
+		}
+		return list, true
+	case []bool:
+		list := make([]interface{}, len(v))
+		for i, b := range v {
+			list[i] = b
+		}
+		return list, true
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			switch e.(type) {
+			case []string, []int, []float64, []bool, []interface{}:
+				return nil, false
+			}
+			castVal, ok := validateContextValue(e)
+			if !ok {
+				return nil, false
+			}
+			list[i] = castVal
+		}
+		return list, true
+	}
+	return nil, false
+}
+
 // NewVisitor returns a new FlagshipVisitor from ID and context
 func (c *FlagshipClient) NewVisitor(visitorID string, context map[string]interface{}) (visitor *FlagshipVisitor, err error) {
 	defer func() {
@@ -2,6 +2,7 @@ package client
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/abtasty/flagship-go-sdk/pkg/tracking"
@@ -108,3 +109,41 @@ func TestSendHitClient(t *testing.T) {
 		t.Errorf("Did not expect error as hit is correct. Got %v", err)
 	}
 }
+
+func TestValidateContextList(t *testing.T) {
+	context := map[string]interface{}{
+		"groups":      []string{"admin", "beta"},
+		"scores":      []interface{}{1, 2.5},
+		"ids":         []int{1, 2},
+		"ratios":      []float64{0.5, 1.5},
+		"flags":       []bool{true, false},
+		"nested_ids":  []interface{}{[]int{1}},
+		"nested":      []interface{}{[]interface{}{"a"}},
+		"wrong_items": []interface{}{errors.New("wrong type")},
+	}
+
+	errs := validateContext(context)
+	if len(errs) != 3 {
+		t.Errorf("Expected 3 context errors, got %v", errs)
+	}
+
+	if !reflect.DeepEqual(context["groups"], []interface{}{"admin", "beta"}) {
+		t.Errorf("String list context key has not been converted. Got %v", context["groups"])
+	}
+
+	if !reflect.DeepEqual(context["scores"], []interface{}{1.0, 2.5}) {
+		t.Errorf("Number list context key has not been converted. Got %v", context["scores"])
+	}
+
+	if !reflect.DeepEqual(context["ids"], []interface{}{1.0, 2.0}) {
+		t.Errorf("Int list context key has not been converted. Got %v", context["ids"])
+	}
+
+	if !reflect.DeepEqual(context["ratios"], []interface{}{0.5, 1.5}) {
+		t.Errorf("Float list context key has not been converted. Got %v", context["ratios"])
+	}
+
+	if !reflect.DeepEqual(context["flags"], []interface{}{true, false}) {
+		t.Errorf("Bool list context key has not been converted. Got %v", context["flags"])
+	}
+}
@@ -103,16 +103,37 @@ func (v *FlagshipVisitor) SynchronizeModifications() (err error) {
 	visitorLogger.Info(fmt.Sprintf("Got %d campaign(s) for visitor with id : %s", len(resp.Campaigns), v.ID))
 	for _, c := range resp.Campaigns {
 		for k, val := range c.Variation.Modifications.Value {
-			v.flagInfos[k] = decision.APIClientFlagInfos{
+			v.setFlagInfos(k, decision.APIClientFlagInfos{
 				Value:    val,
 				Campaign: c,
-			}
+			})
 		}
 	}
 
 	return nil
 }
 
+// setFlagInfos sets the flag infos of a key. When several campaigns set the same key, the campaign
+// with the highest priority wins, then the first campaign of the decision response
+func (v *FlagshipVisitor) setFlagInfos(key string, candidate decision.APIClientFlagInfos) {
+	current, ok := v.flagInfos[key]
+	if !ok {
+		candidate.Candidates = []decision.APIClientFlagInfos{candidate}
+		v.flagInfos[key] = candidate
+		return
+	}
+
+	winner := current
+	if candidate.Campaign.Priority > current.Campaign.Priority {
+		winner = candidate
+	}
+
+	visitorLogger.Warning(fmt.Sprintf("Flag %s is set by campaigns %s and %s. Using value of campaign %s", key, current.Campaign.ID, candidate.Campaign.ID, winner.Campaign.ID))
+
+	winner.Candidates = append(current.Candidates, candidate)
+	v.flagInfos[key] = winner
+}
+
 // getModification gets a flag value as interface{}
 func (v *FlagshipVisitor) getModification(key string, activate bool) (flagValue interface{}, err error) {
 	defer func() {
@@ -134,7 +155,10 @@ func (v *FlagshipVisitor) getModification(key string, activate bool) (flagValue
 		return nil, fmt.Errorf("Key %s not set in decision infos. Fallback to default value", key)
 	}
 
-	if activate {
+	// Held out visitors get the reference variation but must not be counted in the experiment
+	if activate && flagInfos.Campaign.Holdout {
+		visitorLogger.Info(fmt.Sprintf("Visitor with id : %s is held out, campaign for flag %s is not activated", v.ID, key))
+	} else if activate {
 		visitorLogger.Info(fmt.Sprintf("Activating campaign for flag %s for visitor with id : %s", key, v.ID))
 		err := v.trackingAPIClient.ActivateCampaign(tracking.ActivationHit{
 			VariationGroupID: flagInfos.Campaign.VariationGroupID,
@@ -159,6 +183,23 @@ func (v *FlagshipVisitor) getModification(key string, activate bool) (flagValue
 	return flagValue, nil
 }
 
+// GetModificationInfos returns the flag infos of a key, including all the campaigns values for the flag
+func (v *FlagshipVisitor) GetModificationInfos(key string) (flagInfos decision.APIClientFlagInfos, err error) {
+	if v.flagInfos == nil {
+		err := errors.New("Visitor modifications have not been synchronized")
+		visitorLogger.Error("Visitor modifications are not set", err)
+
+		return flagInfos, err
+	}
+
+	flagInfos, ok := v.flagInfos[key]
+	if !ok {
+		return flagInfos, fmt.Errorf("Key %s not set in decision infos", key)
+	}
+
+	return flagInfos, nil
+}
+
 // GetAllModifications return all the modifications
 func (v *FlagshipVisitor) GetAllModifications() (flagInfos map[string]decision.APIClientFlagInfos) {
 	return v.flagInfos
@@ -364,6 +364,62 @@ func TestActivateModification(t *testing.T) {
 	}
 }
 
+// activationRecorder is a tracking API client recording the activation hits
+type activationRecorder struct {
+	*tracking.MockAPIClient
+	hits []tracking.ActivationHit
+}
+
+func (r *activationRecorder) ActivateCampaign(request tracking.ActivationHit) error {
+	r.hits = append(r.hits, request)
+	return nil
+}
+
+func TestActivateModificationHoldout(t *testing.T) {
+	visitor := createVisitor("test", nil)
+	recorder := &activationRecorder{MockAPIClient: tracking.NewMockAPIClient(testEnvID, false)}
+	visitor.trackingAPIClient = recorder
+
+	newCampaign := func(id string, holdout bool) decision.APIClientCampaign {
+		return decision.APIClientCampaign{
+			ID:               id,
+			VariationGroupID: id + "_vgid",
+			Holdout:          holdout,
+			Variation: decision.APIClientVariation{
+				ID: id + "_vid",
+				Modifications: decision.APIClientModification{
+					Type:  "FLAG",
+					Value: map[string]interface{}{id: true},
+				},
+			},
+		}
+	}
+
+	visitor.decisionClient = decision.NewAPIClientMock(testEnvID, &decision.APIClientResponse{
+		VisitorID: "test",
+		Campaigns: []decision.APIClientCampaign{
+			newCampaign("held_out", true),
+			newCampaign("exposed", false),
+		},
+	}, 200)
+
+	err := visitor.SynchronizeModifications()
+	assert.Nil(t, err)
+
+	// Held out visitors get the modification without sending an activation hit
+	val, err := visitor.GetModificationBool("held_out", false, true)
+	assert.Nil(t, err)
+	assert.True(t, val)
+	err = visitor.ActivateModification("held_out")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(recorder.hits))
+
+	err = visitor.ActivateModification("exposed")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(recorder.hits))
+	assert.Equal(t, "exposed_vid", recorder.hits[0].VariationID)
+}
+
 func TestSendHitVisitor(t *testing.T) {
 	visitor := createVisitor("test", nil)
 	err := visitor.SendHit(&tracking.EventHit{})
@@ -379,3 +435,73 @@ func TestSendHitVisitor(t *testing.T) {
 		t.Errorf("Did not expect error as hit is correct. Got %v", err)
 	}
 }
+
+func TestFlagConflicts(t *testing.T) {
+	visitor := createVisitor("test", nil)
+
+	newCampaign := func(id string, priority int, value string) decision.APIClientCampaign {
+		return decision.APIClientCampaign{
+			ID:               id,
+			VariationGroupID: id + "_vgid",
+			Priority:         priority,
+			Variation: decision.APIClientVariation{
+				ID: id + "_vid",
+				Modifications: decision.APIClientModification{
+					Type:  "FLAG",
+					Value: map[string]interface{}{"conflict": value, id: true},
+				},
+			},
+		}
+	}
+
+	visitor.decisionClient = decision.NewAPIClientMock(testEnvID, &decision.APIClientResponse{
+		VisitorID: "test",
+		Campaigns: []decision.APIClientCampaign{
+			newCampaign("c1", 0, "first"),
+			newCampaign("c2", 0, "second"),
+		},
+	}, 200)
+
+	err := visitor.SynchronizeModifications()
+	assert.Nil(t, err)
+
+	// First campaign wins with equal priorities
+	val, err := visitor.GetModificationString("conflict", "default", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", val)
+
+	infos, err := visitor.GetModificationInfos("conflict")
+	assert.Nil(t, err)
+	assert.Equal(t, "c1", infos.Campaign.ID)
+	assert.Equal(t, 2, len(infos.Candidates))
+	assert.Equal(t, "c2", infos.Candidates[1].Campaign.ID)
+	assert.Equal(t, "second", infos.Candidates[1].Value)
+
+	// Highest priority wins whatever the response order
+	visitor.decisionClient = decision.NewAPIClientMock(testEnvID, &decision.APIClientResponse{
+		VisitorID: "test",
+		Campaigns: []decision.APIClientCampaign{
+			newCampaign("c1", 0, "first"),
+			newCampaign("c2", 10, "second"),
+			newCampaign("c3", 5, "third"),
+		},
+	}, 200)
+
+	err = visitor.SynchronizeModifications()
+	assert.Nil(t, err)
+
+	val, err = visitor.GetModificationString("conflict", "default", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", val)
+
+	infos, err = visitor.GetModificationInfos("conflict")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(infos.Candidates))
+
+	infos, err = visitor.GetModificationInfos("c3")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(infos.Candidates))
+
+	_, err = visitor.GetModificationInfos("not_exists")
+	assert.NotNil(t, err)
+}
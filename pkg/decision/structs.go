@@ -21,6 +21,7 @@ type APIClientRequest struct {
 type APIClientResponse struct {
 	VisitorID string              `json:"visitorId"`
 	Panic     bool                `json:"panic"`
+	Holdout   bool                `json:"holdout,omitempty"`
 	Campaigns []APIClientCampaign `json:"campaigns"`
 }
 
@@ -29,6 +30,9 @@ type APIClientCampaign struct {
 	ID               string             `json:"id"`
 	VariationGroupID string             `json:"variationGroupId"`
 	Variation        APIClientVariation `json:"variation"`
+	Type             string             `json:"type,omitempty"`
+	Priority         int                `json:"priority,omitempty"`
+	Holdout          bool               `json:"holdout,omitempty"`
 }
 
 // APIClientVariation represents a decision campaign variation
@@ -48,4 +52,6 @@ type APIClientModification struct {
 type APIClientFlagInfos struct {
 	Value    interface{}
 	Campaign APIClientCampaign
+	// Candidates holds all the campaigns values for the flag, in decision order, when several campaigns set it
+	Candidates []APIClientFlagInfos
 }